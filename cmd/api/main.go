@@ -4,10 +4,14 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
 
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
 	"github.com/Wuzu11517/TxnFlow/internal/config"
 	"github.com/Wuzu11517/TxnFlow/internal/db"
 	httpapi "github.com/Wuzu11517/TxnFlow/internal/http"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/Wuzu11517/TxnFlow/internal/webhook"
 )
 
 func main() {
@@ -21,7 +25,28 @@ func main() {
 	}
 	defer pool.Close()
 
-	handlers := httpapi.NewHandlers(pool)
+	applied, err := db.EnsureSchema(ctx, pool, cfg.AutoMigrate)
+	if err != nil {
+		log.Fatalf("Cannot start API: %v (run migrations or set AUTO_MIGRATE=true)", err)
+	}
+	for _, m := range applied {
+		log.Printf("📦 Applied migration %s", m)
+	}
+
+	// Same registry as the worker, so the API only accepts what it can process
+	chainRegistry, source, err := blockchain.LoadChainRegistry(
+		cfg.ChainsConfigPath, os.Environ(), cfg.InfuraAPIKey, cfg.EthereumFallbackRPCURLs)
+	if err != nil {
+		log.Fatalf("Cannot load chain configuration: %v", err)
+	}
+	log.Printf("Loaded chains from %s: %v", source, chainRegistry.GetSupportedChains())
+
+	// Status changes notify webhook subscribers in the same database transaction
+	txStore := store.NewPostgresStore(pool)
+	txStore.OnTransition = webhook.EnqueueEvent
+
+	handlers := httpapi.NewHandlers(pool, txStore, chainRegistry)
+	handlers.MaxBatchItems = cfg.BatchMaxItems
 	router := httpapi.Router(handlers)
 
 	log.Printf("API listening on :%s", cfg.Port)
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Wuzu11517/TxnFlow/internal/config"
+	"github.com/Wuzu11517/TxnFlow/internal/db"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up              apply every pending migration
+  down [-steps N] revert the latest N migrations (default 1)
+  status          list migrations and whether they are applied
+
+The database is read from DATABASE_URL. txnflowctl migrate does the same.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	err = db.RunCommand(ctx, db.NewMigrator(pool), os.Args[1:], os.Stdout, false)
+	if errors.Is(err, db.ErrCommandUsage) {
+		fmt.Fprint(os.Stderr, usage)
+		pool.Close()
+		os.Exit(2)
+	}
+	if err != nil {
+		pool.Close()
+		log.Fatalf("❌ %v", err)
+	}
+}
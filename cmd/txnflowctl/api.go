@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// submitChunk keeps each batch request under the API's default limit
+const submitChunk = 500
+
+// apiBackend talks to the TxnFlow HTTP API
+type apiBackend struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func newAPIBackend(baseURL string) *apiBackend {
+	return &apiBackend{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request and decodes a 2xx JSON response into out
+func (a *apiBackend) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u := a.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(raw, out)
+	case resp.StatusCode == http.StatusMultipleChoices:
+		var ambiguous struct {
+			Data []models.Transaction `json:"data"`
+		}
+		_ = json.Unmarshal(raw, &ambiguous)
+		return &ambiguousError{Hash: path[strings.LastIndex(path, "/")+1:], Matches: ambiguous.Data}
+	case resp.StatusCode == http.StatusNotFound && strings.TrimSpace(string(raw)) == errNotFound.Error():
+		// Only the handlers' own answer; an unknown route or a proxy's 404
+		// means the API URL is wrong, not that the transaction is missing
+		return errNotFound
+	}
+
+	// Rejections are {"error": {...}} or plain text
+	var apiErr struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("%s: %s (%s)", resp.Status, apiErr.Error.Message, apiErr.Error.Code)
+	}
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+}
+
+func (a *apiBackend) Submit(ctx context.Context, items []submission) ([]submitResult, error) {
+	var results []submitResult
+	for start := 0; start < len(items); start += submitChunk {
+		chunk := items[start:min(start+submitChunk, len(items))]
+
+		var resp struct {
+			Data []submitResult `json:"data"`
+		}
+		err := a.do(ctx, http.MethodPost, "/transactions/batch", nil,
+			map[string]interface{}{"transactions": chunk}, &resp)
+		if err != nil {
+			return results, err
+		}
+
+		for _, r := range resp.Data {
+			r.Index += start
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func (a *apiBackend) Find(ctx context.Context, r ref) (models.Transaction, error) {
+	path := "/transactions/" + url.PathEscape(r.Value)
+	if r.ChainID != nil {
+		path = fmt.Sprintf("/chains/%d/transactions/%s", *r.ChainID, url.PathEscape(r.Value))
+	}
+
+	var txn models.Transaction
+	err := a.do(ctx, http.MethodGet, path, nil, nil, &txn)
+	return txn, err
+}
+
+func (a *apiBackend) List(ctx context.Context, q listQuery) (listPage, error) {
+	query := filterQuery(q.Filter)
+	query.Set("limit", strconv.Itoa(q.Limit))
+	if q.Offset > 0 {
+		query.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Cursor != "" {
+		query.Set("cursor", q.Cursor)
+	}
+
+	var page listPage
+	err := a.do(ctx, http.MethodGet, "/transactions", query, nil, &page)
+	return page, err
+}
+
+func (a *apiBackend) Events(ctx context.Context, txID string) ([]models.Event, error) {
+	var resp struct {
+		Data []models.Event `json:"data"`
+	}
+	err := a.do(ctx, http.MethodGet, "/transactions/"+url.PathEscape(txID)+"/events", nil, nil, &resp)
+	return resp.Data, err
+}
+
+func (a *apiBackend) Requeue(ctx context.Context, txn models.Transaction, reason string) (models.Transaction, error) {
+	var requeued models.Transaction
+	err := a.do(ctx, http.MethodPost, "/transactions/"+url.PathEscape(txn.ID)+"/requeue", nil,
+		map[string]string{"reason": reason}, &requeued)
+	return requeued, err
+}
+
+func (a *apiBackend) Stats(ctx context.Context) (map[string]int, error) {
+	var resp struct {
+		ByStatus map[string]int `json:"by_status"`
+	}
+	err := a.do(ctx, http.MethodGet, "/stats", nil, nil, &resp)
+	return resp.ByStatus, err
+}
+
+// filterQuery renders a filter as the query parameters ListTransactions reads
+func filterQuery(f store.Filter) url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	set("status", string(f.Status))
+	set("from_address", f.FromAddress)
+	set("to_address", f.ToAddress)
+	set("source_service", f.SourceService)
+	set("external_reference", f.ExternalReference)
+	set("metadata", string(f.Metadata))
+	if f.ChainID != nil {
+		set("chain_id", strconv.Itoa(*f.ChainID))
+	}
+	if f.BlockNumberMin != nil {
+		set("block_number_min", strconv.FormatInt(*f.BlockNumberMin, 10))
+	}
+	if f.BlockNumberMax != nil {
+		set("block_number_max", strconv.FormatInt(*f.BlockNumberMax, 10))
+	}
+
+	return query
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// apiStub serves handler and returns a backend pointed at it
+func apiStub(t *testing.T, handler http.HandlerFunc) *apiBackend {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return newAPIBackend(server.URL + "/")
+}
+
+func TestAPIBackendErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		wantNotFound bool
+		wantErr      string
+	}{
+		{
+			name: "transaction not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "transaction not found", http.StatusNotFound)
+			},
+			wantNotFound: true,
+		},
+		{
+			name:    "unknown route",
+			handler: http.NotFound,
+			wantErr: "404 Not Found: 404 page not found",
+		},
+		{
+			name: "structured rejection",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"error": {"code": "not_requeueable", "message": "transaction is CONFIRMED"}}`)
+			},
+			wantErr: "409 Conflict: transaction is CONFIRMED (not_requeueable)",
+		},
+		{
+			name: "plain text failure",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+			},
+			wantErr: "503 Service Unavailable: database unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := apiStub(t, tt.handler).Find(context.Background(), ref{Value: "0xabc"})
+			if got := errors.Is(err, errNotFound); got != tt.wantNotFound {
+				t.Fatalf("Find error = %v, not found %v, want %v", err, got, tt.wantNotFound)
+			}
+			if tt.wantErr != "" && err.Error() != tt.wantErr {
+				t.Errorf("Find error = %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIBackendFindAmbiguous(t *testing.T) {
+	var path string
+	api := apiStub(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusMultipleChoices)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []models.Transaction{{ChainID: 1}, {ChainID: 137}},
+		})
+	})
+
+	_, err := api.Find(context.Background(), ref{Value: "0xabc"})
+	var ambiguous *ambiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Find error = %v, want an ambiguousError", err)
+	}
+	if path != "/transactions/0xabc" || err.Error() != "hash 0xabc is tracked on chains 1, 137; pass -chain" {
+		t.Errorf("Find %s: %v", path, err)
+	}
+
+	chainID := 137
+	api.Find(context.Background(), ref{Value: "0xabc", ChainID: &chainID})
+	if path != "/chains/137/transactions/0xabc" {
+		t.Errorf("scoped Find requested %s", path)
+	}
+}
+
+func TestAPIBackendSubmitChunks(t *testing.T) {
+	var sizes []int
+	api := apiStub(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Transactions []submission `json:"transactions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode batch: %v", err)
+		}
+		sizes = append(sizes, len(req.Transactions))
+
+		results := make([]submitResult, len(req.Transactions))
+		for i, item := range req.Transactions {
+			results[i] = submitResult{Index: i, Result: "created", TransactionHash: item.TransactionHash, ChainID: item.ChainID}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+	})
+
+	items := make([]submission, submitChunk+2)
+	for i := range items {
+		items[i] = submission{TransactionHash: fmt.Sprintf("0x%064x", i), ChainID: 1}
+	}
+
+	results, err := api.Submit(context.Background(), items)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !reflect.DeepEqual(sizes, []int{submitChunk, 2}) {
+		t.Errorf("batch sizes = %v", sizes)
+	}
+	if len(results) != len(items) {
+		t.Fatalf("%d results for %d items", len(results), len(items))
+	}
+	for i, r := range results {
+		if r.Index != i || r.TransactionHash != items[i].TransactionHash {
+			t.Fatalf("result %d = %+v, want index and hash of item %d", i, r, i)
+		}
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	chainID, low, high := 137, int64(100), int64(200)
+	query := filterQuery(store.Filter{
+		Status:         models.StatusConfirmed,
+		FromAddress:    "0xfrom",
+		SourceService:  "payments",
+		Metadata:       json.RawMessage(`{"order":"42"}`),
+		ChainID:        &chainID,
+		BlockNumberMin: &low,
+		BlockNumberMax: &high,
+	})
+
+	want := "block_number_max=200&block_number_min=100&chain_id=137&from_address=0xfrom" +
+		"&metadata=%7B%22order%22%3A%2242%22%7D&source_service=payments&status=CONFIRMED"
+	if got := query.Encode(); got != want {
+		t.Errorf("query = %s, want %s", got, want)
+	}
+	if got := filterQuery(store.Filter{}).Encode(); got != "" {
+		t.Errorf("empty filter = %q", got)
+	}
+}
+
+func TestParseInterleavedFlags(t *testing.T) {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	chainID := fs.Int("chain", 0, "")
+
+	args := parse(fs, strings.Fields("0xaaa -chain 137 0xbbb"))
+	if !reflect.DeepEqual(args, []string{"0xaaa", "0xbbb"}) || *chainID != 137 {
+		t.Errorf("parse = %q, chain %d", args, *chainID)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Wuzu11517/TxnFlow/internal/db"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/Wuzu11517/TxnFlow/internal/webhook"
+)
+
+// backend is what the commands need, served by the API or the database
+type backend interface {
+	Submit(ctx context.Context, items []submission) ([]submitResult, error)
+	Find(ctx context.Context, r ref) (models.Transaction, error)
+	List(ctx context.Context, q listQuery) (listPage, error)
+	Events(ctx context.Context, txID string) ([]models.Event, error)
+	Requeue(ctx context.Context, txn models.Transaction, reason string) (models.Transaction, error)
+	Stats(ctx context.Context) (map[string]int, error)
+}
+
+// ref names a transaction by ID, or by hash with an optional chain
+type ref struct {
+	Value   string
+	ChainID *int
+}
+
+func (r ref) String() string {
+	if r.ChainID != nil {
+		return fmt.Sprintf("%s on chain %d", r.Value, *r.ChainID)
+	}
+	return r.Value
+}
+
+// submission is one item of POST /transactions/batch
+type submission struct {
+	TransactionHash   string `json:"transaction_hash"`
+	ChainID           int    `json:"chain_id"`
+	SourceService     string `json:"source_service,omitempty"`
+	ExternalReference string `json:"external_reference,omitempty"`
+}
+
+// submitResult is the API's verdict on one submission
+type submitResult struct {
+	Index           int           `json:"index"`
+	Result          string        `json:"result"`
+	ID              string        `json:"id,omitempty"`
+	TransactionHash string        `json:"transaction_hash"`
+	ChainID         int           `json:"chain_id"`
+	Status          models.Status `json:"status,omitempty"`
+	Error           *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type listQuery struct {
+	Filter store.Filter
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+type listPage struct {
+	Transactions []models.Transaction `json:"data"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
+}
+
+var errNotFound = errors.New("transaction not found")
+
+// ambiguousError is a bare hash tracked on several chains
+type ambiguousError struct {
+	Hash    string
+	Matches []models.Transaction
+}
+
+func (e *ambiguousError) Error() string {
+	chains := make([]string, len(e.Matches))
+	for i, txn := range e.Matches {
+		chains[i] = fmt.Sprint(txn.ChainID)
+	}
+	return fmt.Sprintf("hash %s is tracked on chains %s; pass -chain", e.Hash, strings.Join(chains, ", "))
+}
+
+// dbBackend works on the database directly, for when the API is unavailable
+type dbBackend struct {
+	Store *store.PostgresStore
+}
+
+func newDBBackend(ctx context.Context, databaseURL string) (backend, func(), error) {
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Never write through a schema this build doesn't understand
+	if err := db.NewMigrator(pool).Check(ctx); err != nil {
+		pool.Close()
+		return nil, nil, err
+	}
+
+	// Requeues notify webhook subscribers just as they do through the API
+	txStore := store.NewPostgresStore(pool)
+	txStore.OnTransition = webhook.EnqueueEvent
+
+	return &dbBackend{Store: txStore}, pool.Close, nil
+}
+
+func (b *dbBackend) Submit(ctx context.Context, items []submission) ([]submitResult, error) {
+	return nil, errors.New("submit needs the API, which validates hashes against the chain registry")
+}
+
+func (b *dbBackend) Find(ctx context.Context, r ref) (models.Transaction, error) {
+	if r.ChainID == nil && models.IsUUID(r.Value) {
+		txn, err := b.Store.Get(ctx, r.Value)
+		if errors.Is(err, store.ErrNotFound) {
+			return txn, errNotFound
+		}
+		return txn, err
+	}
+
+	//hashes are stored normalized to lowercase
+	matches, err := b.Store.FindByHash(ctx, strings.ToLower(r.Value), r.ChainID)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	switch len(matches) {
+	case 0:
+		return models.Transaction{}, errNotFound
+	case 1:
+		return matches[0], nil
+	default:
+		return models.Transaction{}, &ambiguousError{Hash: r.Value, Matches: matches}
+	}
+}
+
+func (b *dbBackend) List(ctx context.Context, q listQuery) (listPage, error) {
+	if q.Cursor != "" {
+		return listPage{}, errors.New("-cursor needs the API; use -offset with -db")
+	}
+
+	transactions, err := b.Store.List(ctx, store.ListQuery{Filter: q.Filter, Limit: q.Limit, Offset: q.Offset})
+	return listPage{Transactions: transactions}, err
+}
+
+func (b *dbBackend) Events(ctx context.Context, txID string) ([]models.Event, error) {
+	return b.Store.Events(ctx, txID)
+}
+
+func (b *dbBackend) Requeue(ctx context.Context, txn models.Transaction, reason string) (models.Transaction, error) {
+	err := b.Store.Requeue(ctx, store.Transition{ID: txn.ID, From: txn.Status, Reason: reason})
+	if err != nil {
+		return txn, err
+	}
+	return b.Store.Get(ctx, txn.ID)
+}
+
+func (b *dbBackend) Stats(ctx context.Context) (map[string]int, error) {
+	return b.Store.CountByStatus(ctx)
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/config"
+	"github.com/Wuzu11517/TxnFlow/internal/db"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// settled are the statuses watch stops at; only a reorg or a requeue moves a
+// transaction on from them
+var settled = map[models.Status]bool{
+	models.StatusConfirmed: true,
+	models.StatusFailed:    true,
+	models.StatusDropped:   true,
+	models.StatusError:     true,
+}
+
+// parse lets flags follow positional arguments, as in "get 0xabc -chain 1"
+func parse(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// refFlags adds -chain, which scopes a hash to one chain
+func refFlags(fs *flag.FlagSet) func(value string) ref {
+	chainID := fs.Int("chain", 0, "chain ID, needed when a hash is tracked on several chains")
+	return func(value string) ref {
+		r := ref{Value: value}
+		if *chainID != 0 {
+			r.ChainID = chainID
+		}
+		return r
+	}
+}
+
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+func orDash(s *string) string {
+	if s == nil || *s == "" {
+		return "-"
+	}
+	return *s
+}
+
+func runSubmit(ctx context.Context, opts options, args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	chainID := fs.Int("chain", 1, "chain ID the hashes are on")
+	file := fs.String("file", "", "read hashes from a file, one per line (- for stdin)")
+	source := fs.String("source", "", "source_service to record")
+	reference := fs.String("ref", "", "external_reference to record")
+	hashes := parse(fs, args)
+
+	if *file != "" {
+		fromFile, err := readHashes(*file)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, fromFile...)
+	}
+	if len(hashes) == 0 {
+		return errUsage
+	}
+
+	items := make([]submission, len(hashes))
+	for i, hash := range hashes {
+		items[i] = submission{TransactionHash: hash, ChainID: *chainID, SourceService: *source, ExternalReference: *reference}
+	}
+
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	results, err := b.Submit(ctx, items)
+	if opts.JSON {
+		if jsonErr := printJSON(results); jsonErr != nil {
+			return jsonErr
+		}
+	} else {
+		table := newTable()
+		fmt.Fprintln(table, "RESULT\tHASH\tCHAIN\tSTATUS\tID")
+		for _, r := range results {
+			if r.Error != nil {
+				fmt.Fprintf(table, "%s\t%s\t%d\t-\t%s\n", r.Result, r.TransactionHash, r.ChainID, r.Error.Message)
+				continue
+			}
+			fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\n", r.Result, r.TransactionHash, r.ChainID, r.Status, r.ID)
+		}
+		table.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	rejected := 0
+	for _, r := range results {
+		if r.Result == "invalid" {
+			rejected++
+		}
+	}
+	if rejected > 0 {
+		return fmt.Errorf("%d of %d hashes rejected", rejected, len(results))
+	}
+	return nil
+}
+
+// readHashes reads one hash per line, skipping blank lines and # comments
+func readHashes(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var hashes []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hashes = append(hashes, line)
+	}
+	return hashes, scanner.Err()
+}
+
+func runGet(ctx context.Context, opts options, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	toRef := refFlags(fs)
+	withEvents := fs.Bool("events", false, "include the status history")
+	refs := parse(fs, args)
+	if len(refs) != 1 {
+		return errUsage
+	}
+
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	txn, err := b.Find(ctx, toRef(refs[0]))
+	if err != nil {
+		return err
+	}
+
+	var events []models.Event
+	if *withEvents {
+		if events, err = b.Events(ctx, txn.ID); err != nil {
+			return err
+		}
+	}
+
+	if opts.JSON {
+		return printJSON(struct {
+			models.Transaction
+			Events []models.Event `json:"events,omitempty"`
+		}{txn, events})
+	}
+
+	printTransaction(txn)
+	if *withEvents {
+		fmt.Println()
+		printEvents(events)
+	}
+	return nil
+}
+
+func printTransaction(txn models.Transaction) {
+	table := newTable()
+	row := func(label, value string) {
+		fmt.Fprintf(table, "%s:\t%s\n", label, value)
+	}
+
+	row("ID", txn.ID)
+	row("Hash", txn.TransactionHash)
+	row("Chain", fmt.Sprint(txn.ChainID))
+	row("Status", string(txn.Status))
+	if txn.ErrorReason != nil {
+		row("Reason", *txn.ErrorReason)
+	}
+	row("From", orDash(txn.FromAddress))
+	row("To", orDash(txn.ToAddress))
+	row("Value", orDash(txn.Value))
+	if txn.BlockNumber != nil {
+		row("Block", fmt.Sprintf("%d (%s)", *txn.BlockNumber, orDash(txn.BlockHash)))
+	}
+	if txn.Confirmations != nil {
+		row("Confirmations", fmt.Sprint(*txn.Confirmations))
+	}
+	row("Attempts", fmt.Sprint(txn.AttemptCount))
+	if txn.LastError != nil {
+		row("Last error", *txn.LastError)
+	}
+	if txn.NextAttemptAt != nil {
+		row("Next attempt", txn.NextAttemptAt.Format(time.RFC3339))
+	}
+	row("Source", orDash(txn.SourceService))
+	row("Reference", orDash(txn.ExternalReference))
+	if len(txn.Metadata) > 0 && string(txn.Metadata) != "{}" {
+		row("Metadata", string(txn.Metadata))
+	}
+	row("Created", txn.CreatedAt.Format(time.RFC3339))
+	row("Updated", txn.UpdatedAt.Format(time.RFC3339))
+	table.Flush()
+}
+
+func runWatch(ctx context.Context, opts options, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	toRef := refFlags(fs)
+	interval := fs.Duration("interval", 2*time.Second, "how often to poll")
+	refs := parse(fs, args)
+	if len(refs) != 1 {
+		return errUsage
+	}
+	r := toRef(refs[0])
+
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	var last models.Status
+	for {
+		txn, err := b.Find(ctx, r)
+		if err != nil {
+			return err
+		}
+
+		if txn.Status != last {
+			if opts.JSON {
+				if err := printJSON(txn); err != nil {
+					return err
+				}
+			} else {
+				line := fmt.Sprintf("%s  %-9s", time.Now().Format(time.TimeOnly), txn.Status)
+				if txn.ErrorReason != nil {
+					line += "  " + *txn.ErrorReason
+				}
+				fmt.Println(line)
+			}
+			last = txn.Status
+		}
+
+		if settled[txn.Status] {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func runList(ctx context.Context, opts options, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	status := fs.String("status", "", "status")
+	chainID := fs.Int("chain", 0, "chain ID")
+	from := fs.String("from", "", "from_address")
+	to := fs.String("to", "", "to_address")
+	source := fs.String("source", "", "source_service")
+	reference := fs.String("ref", "", "external_reference")
+	metadata := fs.String("metadata", "", `metadata containment, e.g. '{"team":"payments"}'`)
+	blockMin := fs.Int64("block-min", -1, "lowest block_number")
+	blockMax := fs.Int64("block-max", -1, "highest block_number")
+	limit := fs.Int("limit", 20, "rows per page (at most 1000)")
+	offset := fs.Int("offset", 0, "rows to skip")
+	cursor := fs.String("cursor", "", "next_cursor from a previous page")
+	if len(parse(fs, args)) != 0 {
+		return errUsage
+	}
+
+	q := listQuery{
+		Filter: store.Filter{
+			Status:            models.Status(strings.ToUpper(*status)),
+			FromAddress:       *from,
+			ToAddress:         *to,
+			SourceService:     *source,
+			ExternalReference: *reference,
+		},
+		Limit:  *limit,
+		Offset: *offset,
+		Cursor: *cursor,
+	}
+	if q.Filter.Status != "" && !q.Filter.Status.Valid() {
+		return fmt.Errorf("unknown status %q", *status)
+	}
+	if *chainID != 0 {
+		q.Filter.ChainID = chainID
+	}
+	if *blockMin >= 0 {
+		q.Filter.BlockNumberMin = blockMin
+	}
+	if *blockMax >= 0 {
+		q.Filter.BlockNumberMax = blockMax
+	}
+	if *metadata != "" {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(*metadata), &obj); err != nil {
+			return errors.New("-metadata must be a JSON object")
+		}
+		q.Filter.Metadata = json.RawMessage(*metadata)
+	}
+
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	page, err := b.List(ctx, q)
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(page)
+	}
+
+	table := newTable()
+	fmt.Fprintln(table, "ID\tHASH\tCHAIN\tSTATUS\tBLOCK\tCREATED")
+	for _, txn := range page.Transactions {
+		block := "-"
+		if txn.BlockNumber != nil {
+			block = fmt.Sprint(*txn.BlockNumber)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			txn.ID, txn.TransactionHash, txn.ChainID, txn.Status, block, txn.CreatedAt.Format(time.RFC3339))
+	}
+	table.Flush()
+
+	if page.NextCursor != "" {
+		fmt.Fprintf(os.Stderr, "more: -cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func runEvents(ctx context.Context, opts options, args []string) error {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	toRef := refFlags(fs)
+	refs := parse(fs, args)
+	if len(refs) != 1 {
+		return errUsage
+	}
+
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	txn, err := b.Find(ctx, toRef(refs[0]))
+	if err != nil {
+		return err
+	}
+	events, err := b.Events(ctx, txn.ID)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(events)
+	}
+	printEvents(events)
+	return nil
+}
+
+func printEvents(events []models.Event) {
+	table := newTable()
+	fmt.Fprintln(table, "SEQ\tTIME\tFROM\tTO\tPROVIDER\tREASON")
+	for _, e := range events {
+		previous := "-"
+		if e.PreviousStatus != nil {
+			previous = string(*e.PreviousStatus)
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			e.Seq, e.CreatedAt.Format(time.RFC3339), previous, e.NewStatus, orDash(e.Provider), orDash(e.Reason))
+	}
+	table.Flush()
+}
+
+func runRequeue(ctx context.Context, opts options, args []string) error {
+	fs := flag.NewFlagSet("requeue", flag.ExitOnError)
+	toRef := refFlags(fs)
+	reason := fs.String("reason", "", "reason recorded in the event history")
+	refs := parse(fs, args)
+	if len(refs) == 0 {
+		return errUsage
+	}
+
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	failed := 0
+	for _, value := range refs {
+		r := toRef(value)
+		txn, err := b.Find(ctx, r)
+		if err == nil {
+			var requeued models.Transaction
+			requeued, err = b.Requeue(ctx, txn, *reason)
+			if err == nil {
+				fmt.Printf("%s: %s → %s\n", txn.TransactionHash, txn.Status, requeued.Status)
+				continue
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", r, err)
+		failed++
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d not requeued", failed, len(refs))
+	}
+	return nil
+}
+
+func runMigrate(ctx context.Context, opts options, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	pool, err := db.Connect(ctx, config.Load().DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	err = db.RunCommand(ctx, db.NewMigrator(pool), args, os.Stdout, opts.JSON)
+	if errors.Is(err, db.ErrCommandUsage) {
+		return errUsage
+	}
+	return err
+}
+
+func runStats(ctx context.Context, opts options, args []string) error {
+	b, closeBackend, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeBackend()
+
+	stats, err := b.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(stats)
+	}
+
+	table := newTable()
+	total := 0
+	for _, status := range models.Statuses() {
+		fmt.Fprintf(table, "%s\t%d\n", status, stats[string(status)])
+		total += stats[string(status)]
+	}
+	fmt.Fprintf(table, "TOTAL\t%d\n", total)
+	return table.Flush()
+}
@@ -0,0 +1,105 @@
+// Command txnflowctl is the operator CLI. It talks to the HTTP API, or with
+// -db straight to the database for break-glass work when the API is down.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Wuzu11517/TxnFlow/internal/config"
+)
+
+const usage = `Usage: txnflowctl [-api URL | -db] [-json] <command> [flags] [args]
+
+Commands:
+  submit    register hashes, from the arguments or one per line with -file
+  get       show a transaction by hash or ID
+  watch     follow a transaction until it reaches a final status
+  list      list transactions with the API's filters
+  events    show a transaction's status history
+  requeue   send ERROR or DROPPED transactions back to RECEIVED
+  migrate   apply, revert or inspect database migrations (always direct)
+  stats     count transactions by status
+
+Global flags:
+`
+
+// errUsage makes main print the usage and exit 2
+var errUsage = errors.New("usage")
+
+// options are the global flags
+type options struct {
+	APIURL string
+	Direct bool
+	JSON   bool
+}
+
+type command func(ctx context.Context, opts options, args []string) error
+
+var commands = map[string]command{
+	"submit":  runSubmit,
+	"get":     runGet,
+	"watch":   runWatch,
+	"list":    runList,
+	"events":  runEvents,
+	"requeue": runRequeue,
+	"migrate": runMigrate,
+	"stats":   runStats,
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.APIURL, "api", envOr("TXNFLOW_API_URL", "http://localhost:8080"), "API base URL ($TXNFLOW_API_URL)")
+	flag.BoolVar(&opts.Direct, "db", false, "bypass the API and use DATABASE_URL directly")
+	flag.BoolVar(&opts.JSON, "json", false, "print JSON instead of tables")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	run, ok := commands[flag.Arg(0)]
+	if !ok {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, opts, flag.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			flag.Usage()
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "txnflowctl %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// connect opens the backend the global flags select
+func connect(ctx context.Context, opts options) (backend, func(), error) {
+	if !opts.Direct {
+		return newAPIBackend(opts.APIURL), func() {}, nil
+	}
+	return newDBBackend(ctx, config.Load().DatabaseURL)
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
 	"github.com/Wuzu11517/TxnFlow/internal/config"
 	"github.com/Wuzu11517/TxnFlow/internal/db"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/Wuzu11517/TxnFlow/internal/webhook"
 	"github.com/Wuzu11517/TxnFlow/internal/worker"
 )
 
@@ -18,16 +23,30 @@ func main() {
 
 	cfg := config.Load()
 
-	// Validate Infura API key
-	if cfg.InfuraAPIKey == "" {
-		log.Println("⚠️  WARNING: INFURA_API_KEY not set")
-		log.Println("   Set environment variable INFURA_API_KEY to use real blockchain data")
-		log.Println("   Get a free API key at https://infura.io")
-		log.Fatal("Cannot start worker without INFURA_API_KEY")
+	// Load chain registry
+	chainRegistry, source, err := blockchain.LoadChainRegistry(
+		cfg.ChainsConfigPath, os.Environ(), cfg.InfuraAPIKey, cfg.EthereumFallbackRPCURLs)
+	if err != nil {
+		log.Println("⚠️  No usable chain configuration")
+		log.Println("   Set CHAINS_CONFIG to a chain registry file, define CHAIN_<ID>_RPC_URLS,")
+		log.Println("   or set INFURA_API_KEY (free key at https://infura.io) for Ethereum mainnet")
+		log.Fatalf("Cannot start worker: %v", err)
 	}
+	log.Printf("Loaded chains from %s", source)
 
-	// Connect to database
+	// Refuse to start against providers serving the wrong chain
 	ctx := context.Background()
+	verifyCtx, cancelVerify := context.WithTimeout(ctx, 30*time.Second)
+	warnings, err := chainRegistry.Verify(verifyCtx)
+	cancelVerify()
+	for _, warning := range warnings {
+		log.Printf("⚠️  %v", warning)
+	}
+	if err != nil {
+		log.Fatalf("Chain endpoint verification failed: %v", err)
+	}
+
+	// Connect to database
 	pool, err := db.Connect(ctx, cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -36,17 +55,66 @@ func main() {
 
 	log.Printf("Connected to database: %s", cfg.DatabaseURL)
 
-	// Initialize chain registry
-	chainRegistry := blockchain.NewChainRegistry(cfg.InfuraAPIKey)
+	applied, err := db.EnsureSchema(ctx, pool, cfg.AutoMigrate)
+	if err != nil {
+		log.Fatalf("Cannot start worker: %v (run migrations or set AUTO_MIGRATE=true)", err)
+	}
+	for _, m := range applied {
+		log.Printf("📦 Applied migration %s", m)
+	}
+
+	// Daily credit budgets are shared by every replica through the database
+	chainRegistry.UseCreditLedger(db.NewCreditLedger(pool))
+
 	supportedChains := chainRegistry.GetSupportedChains()
 	log.Printf("Supported chains: %v", supportedChains)
+	for _, chainID := range supportedChains {
+		chain, _ := chainRegistry.GetChain(chainID)
+		if limit := chain.RateLimit; limit.RequestsPerSecond > 0 || limit.DailyCreditBudget > 0 {
+			log.Printf("   - chain %d rate limit: %.1f req/s (burst %d), daily credit budget %d",
+				chainID, limit.RequestsPerSecond, limit.Burst, limit.DailyCreditBudget)
+		}
+	}
 
 	// Create worker
-	w := worker.NewWorker(pool, chainRegistry)
+	// Status changes notify webhook subscribers in the same database transaction
+	txStore := store.NewPostgresStore(pool)
+	txStore.OnTransition = webhook.EnqueueEvent
+
+	w := worker.NewWorker(pool, txStore, chainRegistry)
+	if cfg.WorkerID != "" {
+		w.WorkerID = cfg.WorkerID
+	}
+	w.BatchSize = cfg.WorkerBatchSize
+	w.Concurrency = cfg.WorkerConcurrency
+	w.LeaseDuration = cfg.WorkerLease
+	w.ReapInterval = cfg.ReapInterval
+	w.MaxAttempts = cfg.RetryMaxAttempts
+	w.RetryBaseDelay = cfg.RetryBaseDelay
+	w.RetryMaxDelay = cfg.RetryMaxDelay
+	w.PendingRecheckBase = cfg.PendingRecheckBase
+	w.PendingRecheckMax = cfg.PendingRecheckMax
+	w.PendingDropAfter = cfg.PendingDropAfter
+	w.ReorgCheckInterval = cfg.ReorgCheckInterval
 	log.Printf("Worker configuration:")
+	log.Printf("   - Worker ID: %s", w.WorkerID)
 	log.Printf("   - Poll interval: %v", w.PollInterval)
 	log.Printf("   - Batch size: %d", w.BatchSize)
-	log.Printf("   - Blockchain: Real Ethereum data via Infura")
+	log.Printf("   - Concurrency: %d (lease %v)", w.Concurrency, w.LeaseDuration)
+	log.Printf("   - Lease reaper interval: %v", w.ReapInterval)
+	log.Printf("   - Provider health checks: every %v", w.HealthCheckInterval)
+	log.Printf("   - Retries: %d attempts, backoff %v up to %v", w.MaxAttempts, w.RetryBaseDelay, w.RetryMaxDelay)
+	log.Printf("   - Pending re-check: %v up to %v, drop after %v", w.PendingRecheckBase, w.PendingRecheckMax, w.PendingDropAfter)
+	log.Printf("   - Reorg check interval: %v", w.ReorgCheckInterval)
+
+	// Webhook deliveries are sent from the worker process
+	dispatcher := webhook.NewDispatcher(pool)
+	dispatcher.MaxAttempts = cfg.WebhookMaxAttempts
+	dispatcher.BaseDelay = cfg.WebhookBaseDelay
+	dispatcher.MaxDelay = cfg.WebhookMaxDelay
+	dispatcher.HTTPClient.Timeout = cfg.WebhookTimeout
+	log.Printf("   - Webhooks: %d attempts, backoff %v up to %v, timeout %v",
+		dispatcher.MaxAttempts, dispatcher.BaseDelay, dispatcher.MaxDelay, cfg.WebhookTimeout)
 
 	// Print initial stats
 	if stats, err := w.GetStats(ctx); err == nil {
@@ -65,6 +133,20 @@ func main() {
 	defer cancel()
 
 	go w.Start(workerCtx)
+	go dispatcher.Start(workerCtx)
+
+	// Live provider health, limiter waits and budgets for operators
+	statusServer := &http.Server{
+		Addr:              ":" + cfg.WorkerStatusPort,
+		Handler:           w.StatusHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := statusServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("⚠️  Status server stopped: %v", err)
+		}
+	}()
+	log.Printf("   - Provider usage: http://localhost:%s/providers", cfg.WorkerStatusPort)
 
 	// Wait for shutdown signal
 	<-sigChan
@@ -72,8 +154,13 @@ func main() {
 
 	// Stop worker gracefully
 	w.Stop()
+	dispatcher.Stop()
 	cancel()
 
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	_ = statusServer.Shutdown(shutdownCtx)
+	cancelShutdown()
+
 	// Print final stats
 	log.Println("\nFinal transaction status counts:")
 	if stats, err := w.GetStats(context.Background()); err == nil {
@@ -81,6 +168,9 @@ func main() {
 			log.Printf("   - %s: %d", status, count)
 		}
 	}
+	log.Printf("Recovered %d stuck transactions during this run", w.RecoveredCount())
+	log.Println("RPC provider usage:")
+	w.LogProviderUsage()
 
 	log.Println("Worker stopped gracefully")
 }
@@ -0,0 +1,17 @@
+// Package backoff computes retry delays shared by the worker and the webhook
+// dispatcher
+package backoff
+
+import "time"
+
+// Exponential returns base * 2^attempt, capped at max
+func Exponential(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,29 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{6, time.Minute},
+		{1000, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := Exponential(time.Second, time.Minute, tt.attempt); got != tt.want {
+			t.Errorf("Exponential(1s, 1m, %d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	if got := Exponential(time.Hour, time.Minute, 0); got != time.Minute {
+		t.Errorf("Exponential with base above max = %v, want the max", got)
+	}
+}
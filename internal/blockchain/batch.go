@@ -0,0 +1,171 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BatchElem is one call within a JSON-RPC batch request
+type BatchElem struct {
+	Method string
+	Params []interface{}
+
+	// Result must be a pointer. It is left untouched when the node returns null.
+	Result interface{}
+
+	// Error is set per element: the call's RPCError, a decode failure, or the
+	// transport error if the whole batch failed
+	Error error
+}
+
+// BatchCall sends all elems as JSON-RPC batches (split at MaxBatchSize) and
+// matches the responses back to their elems by ID. The returned error is only
+// for failures of the batch as a whole; those are also copied onto every elem.
+func (c *RPCClient) BatchCall(ctx context.Context, elems []BatchElem) error {
+	size := c.MaxBatchSize
+	if size <= 0 {
+		size = len(elems)
+	}
+
+	var errs []error
+	for start := 0; start < len(elems); start += size {
+		end := min(start+size, len(elems))
+		if err := c.batchCall(ctx, elems[start:end]); err != nil {
+			for i := start; i < end; i++ {
+				elems[i].Error = err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// batchCall sends a single batch request
+func (c *RPCClient) batchCall(ctx context.Context, elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
+	}
+
+	// IDs are 1-based positions so responses can come back in any order
+	requests := make([]JSONRPCRequest, len(elems))
+	for i, elem := range elems {
+		params := elem.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+		requests[i] = JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  elem.Method,
+			Params:  params,
+			ID:      i + 1,
+		}
+	}
+
+	var raw json.RawMessage
+	if err := c.post(ctx, requests, &raw); err != nil {
+		return err
+	}
+
+	// Some providers answer a rejected batch with a single error object
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var single JSONRPCResponse
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return fmt.Errorf("failed to decode batch response: %w", err)
+		}
+		if single.Error != nil {
+			return single.Error
+		}
+		return fmt.Errorf("unexpected non-batch response")
+	}
+
+	var responses []JSONRPCResponse
+	if err := json.Unmarshal(raw, &responses); err != nil {
+		return fmt.Errorf("failed to decode batch response: %w", err)
+	}
+
+	answered := make([]bool, len(elems))
+	for _, response := range responses {
+		idx := response.ID - 1
+		if idx < 0 || idx >= len(elems) || answered[idx] {
+			continue
+		}
+		answered[idx] = true
+
+		elem := &elems[idx]
+		switch {
+		case response.Error != nil:
+			elem.Error = response.Error
+		case len(response.Result) == 0 || string(response.Result) == "null":
+			// Leave Result untouched
+		case elem.Result != nil:
+			if err := json.Unmarshal(response.Result, elem.Result); err != nil {
+				elem.Error = fmt.Errorf("failed to parse %s result: %w", elem.Method, err)
+			}
+		}
+	}
+
+	for i, ok := range answered {
+		if !ok {
+			elems[i].Error = fmt.Errorf("no response for %s in batch", elems[i].Method)
+		}
+	}
+
+	return nil
+}
+
+// TransactionLookup is the batched result of fetching one hash
+type TransactionLookup struct {
+	Hash    string
+	Tx      *EthTransaction
+	Receipt *EthTransactionReceipt
+
+	// TxErr is ErrTransactionNotFound when the node returned null
+	TxErr error
+	// ReceiptErr is ErrReceiptNotFound while the transaction is pending
+	ReceiptErr error
+}
+
+// GetTransactionsWithReceipts fetches transactions and receipts for all
+// hashes in one round trip (two calls per hash, batched)
+func (c *RPCClient) GetTransactionsWithReceipts(ctx context.Context, hashes []string) ([]TransactionLookup, error) {
+	lookups := make([]TransactionLookup, len(hashes))
+	elems := make([]BatchElem, 0, 2*len(hashes))
+
+	for i, hash := range hashes {
+		lookups[i].Hash = hash
+		elems = append(elems,
+			BatchElem{
+				Method: "eth_getTransactionByHash",
+				Params: []interface{}{hash},
+				Result: &lookups[i].Tx,
+			},
+			BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Params: []interface{}{hash},
+				Result: &lookups[i].Receipt,
+			},
+		)
+	}
+
+	batchErr := c.BatchCall(ctx, elems)
+
+	for i := range lookups {
+		txElem, receiptElem := elems[2*i], elems[2*i+1]
+
+		lookups[i].TxErr = txElem.Error
+		if lookups[i].TxErr == nil && lookups[i].Tx == nil {
+			lookups[i].TxErr = ErrTransactionNotFound
+		}
+
+		lookups[i].ReceiptErr = receiptElem.Error
+		if lookups[i].ReceiptErr == nil && lookups[i].Receipt == nil {
+			lookups[i].ReceiptErr = ErrReceiptNotFound
+		}
+	}
+
+	return lookups, batchErr
+}
@@ -0,0 +1,141 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// rpcStub is a local JSON-RPC node. answer returns the response for one call,
+// or nil to leave the call unanswered.
+func rpcStub(t *testing.T, answer func(req JSONRPCRequest) *JSONRPCResponse) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(rpcHandler(answer))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// rpcHandler serves single and batch JSON-RPC requests from answer
+func rpcHandler(answer func(req JSONRPCRequest) *JSONRPCResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+			var req JSONRPCRequest
+			_ = json.Unmarshal(raw, &req)
+			json.NewEncoder(w).Encode(answer(req))
+			return
+		}
+
+		var reqs []JSONRPCRequest
+		_ = json.Unmarshal(raw, &reqs)
+		responses := []*JSONRPCResponse{}
+		for _, req := range reqs {
+			if resp := answer(req); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}
+}
+
+// result answers req with a JSON result
+func result(req JSONRPCRequest, v interface{}) *JSONRPCResponse {
+	raw, _ := json.Marshal(v)
+	return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: raw}
+}
+
+func TestBatchCallMatchesResponsesByID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&reqs)
+
+		// Answer in reverse, skip one call, and add noise a node might send
+		var responses []*JSONRPCResponse
+		for _, req := range slices.Backward(reqs) {
+			switch req.Method {
+			case "eth_missing":
+			case "eth_fail":
+				responses = append(responses, &JSONRPCResponse{ID: req.ID, Error: &RPCError{Code: -32000, Message: "boom"}})
+			default:
+				responses = append(responses, result(req, req.Method+" "+req.Params[0].(string)))
+			}
+		}
+		responses = append(responses,
+			result(JSONRPCRequest{ID: 99}, "unknown id"),
+			result(JSONRPCRequest{ID: 1}, "duplicate"),
+		)
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	var first, second, third string
+	elems := []BatchElem{
+		{Method: "eth_a", Params: []interface{}{"1"}, Result: &first},
+		{Method: "eth_missing", Params: []interface{}{"2"}, Result: new(string)},
+		{Method: "eth_b", Params: []interface{}{"3"}, Result: &second},
+		{Method: "eth_fail", Params: []interface{}{"4"}, Result: new(string)},
+		{Method: "eth_c", Params: []interface{}{"5"}, Result: &third},
+	}
+
+	if err := NewRPCClient(server.URL).BatchCall(context.Background(), elems); err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+
+	if first != "eth_a 1" || second != "eth_b 3" || third != "eth_c 5" {
+		t.Errorf("results = %q, %q, %q", first, second, third)
+	}
+	for _, i := range []int{0, 2, 4} {
+		if elems[i].Error != nil {
+			t.Errorf("%s error = %v", elems[i].Method, elems[i].Error)
+		}
+	}
+	if err := elems[1].Error; err == nil || !strings.Contains(err.Error(), "no response for eth_missing") {
+		t.Errorf("unanswered call error = %v", err)
+	}
+	if rpcErr, ok := elems[3].Error.(*RPCError); !ok || rpcErr.Message != "boom" {
+		t.Errorf("failed call error = %v", elems[3].Error)
+	}
+}
+
+func TestBatchCallSplitsAtMaxBatchSize(t *testing.T) {
+	var posts atomic.Int32
+	echo := rpcHandler(func(req JSONRPCRequest) *JSONRPCResponse {
+		return result(req, req.Params[0])
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		echo(w, r)
+	}))
+	defer server.Close()
+
+	client := NewRPCClient(server.URL)
+	client.MaxBatchSize = 2
+
+	results := make([]string, 5)
+	elems := make([]BatchElem, len(results))
+	for i := range elems {
+		elems[i] = BatchElem{Method: "eth_echo", Params: []interface{}{string(rune('a' + i))}, Result: &results[i]}
+	}
+
+	if err := client.BatchCall(context.Background(), elems); err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+	if got := strings.Join(results, ""); got != "abcde" {
+		t.Errorf("results = %q, want abcde", got)
+	}
+	if got := posts.Load(); got != 3 {
+		t.Errorf("%d batch requests, want 3", got)
+	}
+}
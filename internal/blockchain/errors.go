@@ -0,0 +1,88 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// HTTPError is returned when the RPC endpoint answers with a non-200 status
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("RPC returned HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Standard JSON-RPC 2.0 and Ethereum node error codes
+const (
+	rpcCodeParseError      = -32700
+	rpcCodeInvalidRequest  = -32600
+	rpcCodeMethodNotFound  = -32601
+	rpcCodeInvalidParams   = -32602
+	rpcCodeInternalError   = -32603
+	rpcCodeLimitExceeded   = -32005 // Infura/Alchemy rate limiting
+	rpcCodeServerErrorLow  = -32099 // -32000 to -32099 is reserved for
+	rpcCodeServerErrorHigh = -32000 // implementation-defined server errors
+)
+
+// IsRetryable reports whether an error from the RPC layer is likely to go
+// away on its own (timeouts, rate limits, lagging nodes) as opposed to one
+// that will fail the same way every time (bad input, unsupported chain).
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrUnsupportedChain) {
+		return false
+	}
+
+	// A node that is behind may simply not have seen the transaction yet
+	if errors.Is(err, ErrTransactionNotFound) || errors.Is(err, ErrReceiptNotFound) {
+		return true
+	}
+
+	// Budgets reset daily and limiter waits can be cut short by deadlines
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrBudgetExhausted) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch {
+		case httpErr.StatusCode == http.StatusTooManyRequests,
+			httpErr.StatusCode == http.StatusRequestTimeout,
+			httpErr.StatusCode == http.StatusTooEarly,
+			httpErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		switch {
+		case rpcErr.Code == rpcCodeParseError,
+			rpcErr.Code == rpcCodeInvalidRequest,
+			rpcErr.Code == rpcCodeMethodNotFound,
+			rpcErr.Code == rpcCodeInvalidParams:
+			return false
+		case rpcErr.Code == rpcCodeInternalError,
+			rpcErr.Code == rpcCodeLimitExceeded,
+			rpcErr.Code >= rpcCodeServerErrorLow && rpcErr.Code <= rpcCodeServerErrorHigh:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Connection refused/reset, DNS failures and client timeouts
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -4,25 +4,55 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"time"
 )
 
+// ErrTransactionNotFound is returned when the node has no record of a transaction
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrReceiptNotFound is returned when a transaction has not been mined yet
+var ErrReceiptNotFound = errors.New("receipt not found (transaction may be pending)")
+
+// RPCClient talks JSON-RPC to one or more providers for the same chain.
+// Requests go to the healthiest provider and fail over to the next one when
+// a provider errors or falls behind.
 type RPCClient struct {
-	RPCURL     string
 	HTTPClient *http.Client
+
+	// MaxBatchSize caps how many calls go into one batch request;
+	// larger batches are split. Providers reject oversized batches.
+	MaxBatchSize int
+
+	// MaxHeadLag is how many blocks a provider may trail the best known
+	// head before it is only used as a last resort. Zero disables the check.
+	MaxHeadLag int64
+
+	endpoints []*endpoint
 }
 
 // NewRPCClient creates a new Ethereum RPC client
 func NewRPCClient(rpcURL string) *RPCClient {
-	return &RPCClient{
-		RPCURL: rpcURL,
+	return NewFailoverRPCClient([]Endpoint{{Name: "default", URL: rpcURL}})
+}
+
+// NewFailoverRPCClient creates a client over an ordered list of providers.
+// Earlier endpoints are preferred when health is otherwise equal.
+func NewFailoverRPCClient(endpoints []Endpoint) *RPCClient {
+	c := &RPCClient{
 		HTTPClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		MaxBatchSize: 100,
 	}
+	for _, e := range endpoints {
+		c.endpoints = append(c.endpoints, &endpoint{Endpoint: e, limiter: newRateLimiter(e.RateLimit)})
+	}
+	return c
 }
 
 // JSONRPCRequest represents a JSON-RPC 2.0 request
@@ -43,8 +73,9 @@ type JSONRPCResponse struct {
 
 // RPCError represents a JSON-RPC error
 type RPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
 func (e *RPCError) Error() string {
@@ -95,7 +126,7 @@ func (c *RPCClient) GetTransactionByHash(ctx context.Context, txHash string) (*E
 
 	// Check if result is null (transaction not found)
 	if string(response.Result) == "null" {
-		return nil, fmt.Errorf("transaction not found")
+		return nil, ErrTransactionNotFound
 	}
 
 	var tx EthTransaction
@@ -126,7 +157,7 @@ func (c *RPCClient) GetTransactionReceipt(ctx context.Context, txHash string) (*
 
 	// Check if result is null (receipt not found - transaction might be pending)
 	if string(response.Result) == "null" {
-		return nil, fmt.Errorf("receipt not found (transaction may be pending)")
+		return nil, ErrReceiptNotFound
 	}
 
 	var receipt EthTransactionReceipt
@@ -137,22 +168,293 @@ func (c *RPCClient) GetTransactionReceipt(ctx context.Context, txHash string) (*
 	return &receipt, nil
 }
 
+// EthBlock is the header subset of eth_getBlockByNumber we rely on
+type EthBlock struct {
+	Number     string `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// BlockTag names a block relative to the node's view of the chain
+type BlockTag string
+
+const (
+	BlockTagLatest    BlockTag = "latest"
+	BlockTagSafe      BlockTag = "safe"      // Unlikely to be reorged (post-merge chains)
+	BlockTagFinalized BlockTag = "finalized" // Cannot be reorged without slashing
+)
+
+// GetBlockNumber returns the number of the most recent block
+func (c *RPCClient) GetBlockNumber(ctx context.Context) (int64, error) {
+	request := JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_blockNumber",
+		Params:  []interface{}{},
+		ID:      1,
+	}
+
+	var response JSONRPCResponse
+	if err := c.call(ctx, request, &response); err != nil {
+		return 0, err
+	}
+
+	if response.Error != nil {
+		return 0, response.Error
+	}
+
+	var result string
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse block number: %w", err)
+	}
+
+	return HexToInt64(result)
+}
+
+// GetBlockByNumber fetches a block header by hex number or tag (latest/safe/finalized)
+func (c *RPCClient) GetBlockByNumber(ctx context.Context, block string) (*EthBlock, error) {
+	request := JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_getBlockByNumber",
+		Params:  []interface{}{block, false},
+		ID:      1,
+	}
+
+	var response JSONRPCResponse
+	if err := c.call(ctx, request, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Null result means the block doesn't exist yet (or the tag isn't supported)
+	if string(response.Result) == "null" {
+		return nil, fmt.Errorf("block %s not found", block)
+	}
+
+	var blk EthBlock
+	if err := json.Unmarshal(response.Result, &blk); err != nil {
+		return nil, fmt.Errorf("failed to parse block: %w", err)
+	}
+
+	return &blk, nil
+}
+
+// EthCallMsg is the call object passed to eth_call
+type EthCallMsg struct {
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Gas   string `json:"gas,omitempty"`
+	Value string `json:"value,omitempty"`
+	Data  string `json:"data,omitempty"`
+}
+
+// Call executes eth_call against the given block and returns the raw return data
+func (c *RPCClient) Call(ctx context.Context, msg EthCallMsg, block string) (string, error) {
+	request := JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_call",
+		Params:  []interface{}{msg, block},
+		ID:      1,
+	}
+
+	var response JSONRPCResponse
+	if err := c.call(ctx, request, &response); err != nil {
+		return "", err
+	}
+
+	if response.Error != nil {
+		return "", response.Error
+	}
+
+	var result string
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse call result: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetRevertReason replays a mined transaction with eth_call and returns the
+// decoded revert reason. An empty string means the node did not report one.
+func (c *RPCClient) GetRevertReason(ctx context.Context, tx *EthTransaction) (string, error) {
+	msg := EthCallMsg{
+		From:  tx.From,
+		To:    tx.To,
+		Gas:   tx.Gas,
+		Value: tx.Value,
+		Data:  tx.Input,
+	}
+
+	_, err := c.Call(ctx, msg, replayBlock(tx.BlockNumber))
+	if err == nil {
+		// The call succeeded on replay, so the revert depended on state we can't reproduce
+		return "", nil
+	}
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return "", err
+	}
+
+	return rpcErr.RevertReason(), nil
+}
+
+// replayBlock is the block to replay a mined transaction on top of: the one
+// before its own, so the state excludes its block-mates and anything later.
+// An unknown block can only be replayed against latest.
+func replayBlock(blockNumber string) string {
+	n, err := HexToInt64(blockNumber)
+	if blockNumber == "" || err != nil || n == 0 {
+		return string(BlockTagLatest)
+	}
+	return Int64ToHex(n - 1)
+}
+
 // call makes a JSON-RPC call to the Ethereum node
 func (c *RPCClient) call(ctx context.Context, request JSONRPCRequest, response *JSONRPCResponse) error {
+	return c.post(ctx, request, response)
+}
+
+// post sends a JSON-RPC payload (a single request or a batch) and decodes the
+// reply into out, failing over across endpoints until one of them answers
+// cleanly. If every endpoint fails but some answered with JSON-RPC errors,
+// the last such answer is left in out for the caller to inspect.
+func (c *RPCClient) post(ctx context.Context, body interface{}, out interface{}) error {
+	if len(c.endpoints) == 0 {
+		return fmt.Errorf("no RPC endpoints configured")
+	}
+
+	// Each endpoint decodes into its own value so one's error can't linger
+	// in the next one's answer
+	result := reflect.ValueOf(out).Elem()
+	var (
+		errs       []error
+		answeredBy string
+		answer     reflect.Value
+	)
+	for _, e := range c.rankEndpoints() {
+		attempt := reflect.New(result.Type())
+		err := c.postTo(ctx, e, body, attempt.Interface())
+		if err == nil {
+			result.Set(attempt.Elem())
+			recordProvider(ctx, e.Name)
+			return nil
+		}
+
+		var degraded *degradedResponseError
+		if errors.As(err, &degraded) {
+			answeredBy, answer = e.Name, attempt
+		}
+		errs = append(errs, fmt.Errorf("provider %s: %w", e.Name, err))
+
+		// Don't burn through the other providers once the caller has given up
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if answeredBy != "" {
+		result.Set(answer.Elem())
+		recordProvider(ctx, answeredBy)
+		return nil
+	}
+	if len(errs) == 1 {
+		return errors.Unwrap(errs[0])
+	}
+	return errors.Join(errs...)
+}
+
+// postTo sends a payload to one endpoint and records the outcome in its health
+func (c *RPCClient) postTo(ctx context.Context, e *endpoint, body interface{}, out interface{}) error {
+	// Wait our turn with this provider, or bail out if its budget is spent
+	if err := e.limiter.wait(ctx, body); err != nil {
+		return err
+	}
+
 	// Serialize request
-	payload, err := json.Marshal(request)
+	payload, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", c.RPCURL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, "POST", e.URL, bytes.NewBuffer(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
+	err = c.do(req, out)
+	if err == nil {
+		// A 200 can still carry a rate limit or a node-side failure
+		if rpcErr := retryableRPCError(out); rpcErr != nil {
+			err = &degradedResponseError{rpcErr}
+		}
+	}
+	if ctx.Err() == nil {
+		// A cancelled caller says nothing about the provider
+		e.record(err, time.Since(start))
+	}
+	return err
+}
+
+// degradedResponseError is a 200 response whose JSON-RPC error says the
+// provider, not the request, is at fault: rate limiting or a server error
+type degradedResponseError struct {
+	err *RPCError
+}
+
+func (e *degradedResponseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *degradedResponseError) Unwrap() error {
+	return e.err
+}
+
+// retryableRPCError returns the first retryable JSON-RPC error in a decoded
+// response or batch. Reverts are the call's own outcome, not the provider's.
+func retryableRPCError(out interface{}) *RPCError {
+	degraded := func(rpcErr *RPCError) bool {
+		return rpcErr != nil && IsRetryable(rpcErr) && !rpcErr.isRevert()
+	}
+
+	switch resp := out.(type) {
+	case *JSONRPCResponse:
+		if degraded(resp.Error) {
+			return resp.Error
+		}
+	case *json.RawMessage:
+		// Batches, or the single error object a provider rejects a batch with
+		trimmed := bytes.TrimSpace(*resp)
+		if len(trimmed) > 0 && trimmed[0] == '{' {
+			var single JSONRPCResponse
+			if json.Unmarshal(trimmed, &single) == nil && degraded(single.Error) {
+				return single.Error
+			}
+			return nil
+		}
+
+		var responses []JSONRPCResponse
+		if json.Unmarshal(trimmed, &responses) != nil {
+			return nil
+		}
+		for _, r := range responses {
+			if degraded(r.Error) {
+				return r.Error
+			}
+		}
+	}
+	return nil
+}
+
+// do executes the HTTP request and decodes a 200 response into out
+func (c *RPCClient) do(req *http.Request, out interface{}) error {
 	// Execute request
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -163,11 +465,11 @@ func (c *RPCClient) call(ctx context.Context, request JSONRPCRequest, response *
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("RPC returned HTTP %d: %s", resp.StatusCode, string(body))
+		return &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Parse response
-	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -0,0 +1,316 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// chainsFile is the on-disk format of a chain registry config file
+type chainsFile struct {
+	Chains []*ChainConfig `json:"chains"`
+}
+
+// LoadChainRegistry builds the registry from the first configured source:
+// a JSON file at path, then CHAIN_<ID>_* environment variables, then the
+// built-in Infura mainnet default. It returns a description of the source.
+func LoadChainRegistry(path string, environ []string, infuraAPIKey string, fallbackURLs []string) (*ChainRegistry, string, error) {
+	if path != "" {
+		registry, err := LoadChainRegistryFile(path)
+		return registry, "file " + path, err
+	}
+
+	registry, err := LoadChainRegistryEnv(environ)
+	if err != nil {
+		return nil, "", err
+	}
+	if registry != nil {
+		return registry, "environment", nil
+	}
+
+	if infuraAPIKey == "" {
+		return nil, "", fmt.Errorf("no chains configured: set CHAINS_CONFIG, CHAIN_<ID>_RPC_URLS or INFURA_API_KEY")
+	}
+	return NewChainRegistry(infuraAPIKey, fallbackURLs...), "built-in Infura default", nil
+}
+
+// LoadChainRegistryFile reads chain configurations from a JSON file.
+// ${VAR} references in endpoint URLs are expanded so API keys can stay in the environment.
+func LoadChainRegistryFile(path string) (*ChainRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain config: %w", err)
+	}
+
+	var file chainsFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse chain config %s: %w", path, err)
+	}
+
+	for _, chain := range file.Chains {
+		for i := range chain.Endpoints {
+			chain.Endpoints[i].URL = os.ExpandEnv(chain.Endpoints[i].URL)
+		}
+	}
+
+	return newRegistryFromConfigs(file.Chains)
+}
+
+// Defaults for chains configured through the environment, matching the
+// built-in mainnet config. Leaving them at zero would confirm transactions
+// at the chain head and never fail over from a lagging provider.
+const (
+	defaultConfirmationDepth = 12
+	defaultReorgWindow       = 64
+	defaultMaxHeadLag        = 5
+)
+
+// chainEnvPattern matches CHAIN_<ID>_<SETTING> variables
+var chainEnvPattern = regexp.MustCompile(`^CHAIN_(\d+)_([A-Z_]+)$`)
+
+// LoadChainRegistryEnv builds chains from CHAIN_<ID>_* variables, e.g.
+//
+//	CHAIN_137_NAME=Polygon
+//	CHAIN_137_RPC_URLS=https://polygon-rpc.com,https://rpc.ankr.com/polygon
+//	CHAIN_137_CONFIRMATION_DEPTH=64
+//
+// A chain is defined by the presence of CHAIN_<ID>_RPC_URLS. Unset depths
+// take the mainnet defaults. It returns a nil registry when no chain is defined.
+func LoadChainRegistryEnv(environ []string) (*ChainRegistry, error) {
+	settings := make(map[int]map[string]string)
+
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		m := chainEnvPattern.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		chainID, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		if settings[chainID] == nil {
+			settings[chainID] = make(map[string]string)
+		}
+		settings[chainID][m[2]] = value
+	}
+
+	ids := make([]int, 0, len(settings))
+	for chainID, s := range settings {
+		if s["RPC_URLS"] != "" {
+			ids = append(ids, chainID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sort.Ints(ids)
+
+	var configs []*ChainConfig
+	for _, chainID := range ids {
+		config, err := chainConfigFromEnv(chainID, settings[chainID])
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+
+	return newRegistryFromConfigs(configs)
+}
+
+// chainConfigFromEnv parses one chain's CHAIN_<ID>_* settings
+func chainConfigFromEnv(chainID int, s map[string]string) (*ChainConfig, error) {
+	config := &ChainConfig{
+		ChainID:     chainID,
+		Name:        s["NAME"],
+		Type:        ChainType(s["TYPE"]),
+		FinalityTag: BlockTag(s["FINALITY_TAG"]),
+		NativeCurrency: NativeCurrency{
+			Symbol: s["CURRENCY_SYMBOL"],
+		},
+
+		ConfirmationDepth: defaultConfirmationDepth,
+		ReorgWindow:       defaultReorgWindow,
+		MaxHeadLag:        defaultMaxHeadLag,
+	}
+
+	for i, url := range strings.Split(s["RPC_URLS"], ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			config.Endpoints = append(config.Endpoints, Endpoint{Name: fmt.Sprintf("endpoint-%d", i+1), URL: url})
+		}
+	}
+
+	var errs []error
+	parseInt := func(key string, dst *int64) {
+		if v := s[key]; v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("CHAIN_%d_%s: %w", chainID, key, err))
+			}
+			*dst = n
+		}
+	}
+
+	var decimals, burst int64
+	parseInt("CONFIRMATION_DEPTH", &config.ConfirmationDepth)
+	parseInt("REORG_WINDOW", &config.ReorgWindow)
+	parseInt("MAX_HEAD_LAG", &config.MaxHeadLag)
+	parseInt("DAILY_CREDIT_BUDGET", &config.RateLimit.DailyCreditBudget)
+	parseInt("BURST", &burst)
+	parseInt("CURRENCY_DECIMALS", &decimals)
+	config.RateLimit.Burst = int(burst)
+	config.NativeCurrency.Decimals = int(decimals)
+
+	if v := s["RPS"]; v != "" {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CHAIN_%d_RPS: %w", chainID, err))
+		}
+		config.RateLimit.RequestsPerSecond = rps
+	}
+
+	return config, errors.Join(errs...)
+}
+
+// newRegistryFromConfigs validates configs, fills in defaults and registers them
+func newRegistryFromConfigs(configs []*ChainConfig) (*ChainRegistry, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("chain config defines no chains")
+	}
+
+	registry := &ChainRegistry{
+		chains:  make(map[int]*ChainConfig),
+		clients: make(map[int]*RPCClient),
+	}
+
+	for _, config := range configs {
+		if err := applyChainDefaults(config); err != nil {
+			return nil, err
+		}
+		if registry.IsSupported(config.ChainID) {
+			return nil, fmt.Errorf("chain %d is configured twice", config.ChainID)
+		}
+		registry.RegisterChain(config)
+	}
+
+	return registry, nil
+}
+
+// applyChainDefaults validates a chain config and fills in optional fields
+func applyChainDefaults(config *ChainConfig) error {
+	if config.ChainID <= 0 {
+		return fmt.Errorf("chain %q: chain_id must be positive", config.Name)
+	}
+	if config.Name == "" {
+		config.Name = fmt.Sprintf("Chain %d", config.ChainID)
+	}
+
+	if config.Type == "" {
+		config.Type = ChainTypeEVM
+	}
+	if config.Type != ChainTypeEVM {
+		return fmt.Errorf("chain %d: unsupported type %q", config.ChainID, config.Type)
+	}
+
+	switch config.FinalityTag {
+	case "", BlockTagLatest, BlockTagSafe, BlockTagFinalized:
+	default:
+		return fmt.Errorf("chain %d: unknown finality_tag %q", config.ChainID, config.FinalityTag)
+	}
+
+	if len(config.Endpoints) == 0 {
+		return fmt.Errorf("chain %d: at least one endpoint is required", config.ChainID)
+	}
+	for i := range config.Endpoints {
+		e := &config.Endpoints[i]
+		if e.URL == "" {
+			return fmt.Errorf("chain %d: endpoint %d has no url", config.ChainID, i+1)
+		}
+		if e.Name == "" {
+			e.Name = fmt.Sprintf("endpoint-%d", i+1)
+		}
+	}
+
+	if config.ConfirmationDepth < 0 || config.ReorgWindow < 0 || config.MaxHeadLag < 0 {
+		return fmt.Errorf("chain %d: depths and windows must not be negative", config.ChainID)
+	}
+	if config.ConfirmationDepth == 0 && (config.FinalityTag == "" || config.FinalityTag == BlockTagLatest) {
+		// Nothing would stand between inclusion and CONFIRMED
+		return fmt.Errorf("chain %d: confirmation_depth 0 requires a safe or finalized finality_tag", config.ChainID)
+	}
+
+	if config.NativeCurrency.Symbol == "" {
+		config.NativeCurrency.Symbol = "ETH"
+	}
+	if config.NativeCurrency.Decimals == 0 {
+		config.NativeCurrency.Decimals = 18
+	}
+
+	return nil
+}
+
+// Verify checks every endpoint of every chain with eth_chainId. A provider
+// reporting a different chain is a misconfiguration and fails verification;
+// providers that can't be reached are returned as warnings since they may
+// just be down right now.
+func (r *ChainRegistry) Verify(ctx context.Context) ([]error, error) {
+	var warnings, errs []error
+
+	for _, chainID := range r.GetSupportedChains() {
+		client, err := r.Client(chainID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range client.endpoints {
+			reported, err := client.chainIDAt(ctx, e)
+			if err != nil {
+				warnings = append(warnings, fmt.Errorf("chain %d endpoint %s unreachable: %w", chainID, e.Name, err))
+				continue
+			}
+			if reported != int64(chainID) {
+				errs = append(errs, fmt.Errorf("chain %d endpoint %s reports chain ID %d", chainID, e.Name, reported))
+			}
+		}
+	}
+
+	return warnings, errors.Join(errs...)
+}
+
+// chainIDAt asks a single endpoint for its chain ID, bypassing failover
+func (c *RPCClient) chainIDAt(ctx context.Context, e *endpoint) (int64, error) {
+	request := JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_chainId",
+		Params:  []interface{}{},
+		ID:      1,
+	}
+
+	var response JSONRPCResponse
+	if err := c.postTo(ctx, e, request, &response); err != nil {
+		return 0, err
+	}
+
+	if response.Error != nil {
+		return 0, response.Error
+	}
+
+	var result string
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse chain ID: %w", err)
+	}
+
+	return HexToInt64(result)
+}
@@ -0,0 +1,257 @@
+package blockchain
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chainIDStub is a node answering eth_chainId with chainID
+func chainIDStub(t *testing.T, chainID int64) string {
+	return rpcStub(t, func(req JSONRPCRequest) *JSONRPCResponse {
+		return result(req, Int64ToHex(chainID))
+	}).URL
+}
+
+func TestLoadChainRegistryEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		check   func(t *testing.T, registry *ChainRegistry)
+		wantErr string
+	}{
+		{
+			name:    "no chains",
+			environ: []string{"PATH=/usr/bin", "CHAIN_1_NAME=orphan"},
+			check: func(t *testing.T, registry *ChainRegistry) {
+				if registry != nil {
+					t.Errorf("registry = %v, want nil without RPC_URLS", registry.GetSupportedChains())
+				}
+			},
+		},
+		{
+			name: "full settings",
+			environ: []string{
+				"CHAIN_137_NAME=Polygon",
+				"CHAIN_137_RPC_URLS=https://a.example, ,https://b.example",
+				"CHAIN_137_CONFIRMATION_DEPTH=64",
+				"CHAIN_137_FINALITY_TAG=finalized",
+				"CHAIN_137_REORG_WINDOW=256",
+				"CHAIN_137_MAX_HEAD_LAG=10",
+				"CHAIN_137_RPS=2.5",
+				"CHAIN_137_BURST=4",
+				"CHAIN_137_DAILY_CREDIT_BUDGET=1000",
+				"CHAIN_137_CURRENCY_SYMBOL=POL",
+			},
+			check: func(t *testing.T, registry *ChainRegistry) {
+				chain, err := registry.GetChain(137)
+				if err != nil {
+					t.Fatalf("GetChain: %v", err)
+				}
+				if chain.Name != "Polygon" || chain.Type != ChainTypeEVM || chain.FinalityTag != BlockTagFinalized {
+					t.Errorf("chain = %+v", chain)
+				}
+				if len(chain.Endpoints) != 2 || chain.Endpoints[0].URL != "https://a.example" || chain.Endpoints[1].URL != "https://b.example" {
+					t.Errorf("endpoints = %+v", chain.Endpoints)
+				}
+				if chain.ConfirmationDepth != 64 || chain.ReorgWindow != 256 || chain.MaxHeadLag != 10 {
+					t.Errorf("depths = %d/%d/%d", chain.ConfirmationDepth, chain.ReorgWindow, chain.MaxHeadLag)
+				}
+				if rl := chain.RateLimit; rl.RequestsPerSecond != 2.5 || rl.Burst != 4 || rl.DailyCreditBudget != 1000 {
+					t.Errorf("rate limit = %+v", rl)
+				}
+				if chain.NativeCurrency != (NativeCurrency{Symbol: "POL", Decimals: 18}) {
+					t.Errorf("currency = %+v", chain.NativeCurrency)
+				}
+			},
+		},
+		{
+			name:    "unset depths take the defaults",
+			environ: []string{"CHAIN_10_RPC_URLS=https://a.example"},
+			check: func(t *testing.T, registry *ChainRegistry) {
+				chain, err := registry.GetChain(10)
+				if err != nil {
+					t.Fatalf("GetChain: %v", err)
+				}
+				if chain.Name != "Chain 10" {
+					t.Errorf("name = %q", chain.Name)
+				}
+				if chain.ConfirmationDepth != defaultConfirmationDepth || chain.ReorgWindow != defaultReorgWindow || chain.MaxHeadLag != defaultMaxHeadLag {
+					t.Errorf("depths = %d/%d/%d, want the defaults", chain.ConfirmationDepth, chain.ReorgWindow, chain.MaxHeadLag)
+				}
+			},
+		},
+		{
+			name:    "depth 0 with a finality tag",
+			environ: []string{"CHAIN_10_RPC_URLS=https://a.example", "CHAIN_10_CONFIRMATION_DEPTH=0", "CHAIN_10_FINALITY_TAG=safe"},
+			check: func(t *testing.T, registry *ChainRegistry) {
+				chain, _ := registry.GetChain(10)
+				if chain.ConfirmationDepth != 0 || chain.FinalityTag != BlockTagSafe {
+					t.Errorf("chain = %+v", chain)
+				}
+			},
+		},
+		{
+			name:    "depth 0 without a finality tag",
+			environ: []string{"CHAIN_10_RPC_URLS=https://a.example", "CHAIN_10_CONFIRMATION_DEPTH=0"},
+			wantErr: "confirmation_depth 0 requires",
+		},
+		{
+			name:    "malformed number",
+			environ: []string{"CHAIN_10_RPC_URLS=https://a.example", "CHAIN_10_CONFIRMATION_DEPTH=twelve"},
+			wantErr: "CHAIN_10_CONFIRMATION_DEPTH",
+		},
+		{
+			name:    "malformed rps",
+			environ: []string{"CHAIN_10_RPC_URLS=https://a.example", "CHAIN_10_RPS=fast"},
+			wantErr: "CHAIN_10_RPS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := LoadChainRegistryEnv(tt.environ)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadChainRegistryEnv: %v", err)
+			}
+			tt.check(t, registry)
+		})
+	}
+}
+
+func TestLoadChainRegistryFile(t *testing.T) {
+	t.Setenv("TEST_RPC_KEY", "secret")
+
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			json: `{"chains": [{"chain_id": 1, "endpoints": [{"url": "https://node.example/${TEST_RPC_KEY}"}], "confirmation_depth": 12}]}`,
+		},
+		{
+			name:    "unknown field",
+			json:    `{"chains": [{"chain_id": 1, "endpoints": [{"url": "https://a.example"}], "confirmations": 12}]}`,
+			wantErr: "unknown field",
+		},
+		{
+			name:    "no chains",
+			json:    `{"chains": []}`,
+			wantErr: "defines no chains",
+		},
+		{
+			name: "duplicate chain",
+			json: `{"chains": [
+				{"chain_id": 1, "endpoints": [{"url": "https://a.example"}], "confirmation_depth": 12},
+				{"chain_id": 1, "endpoints": [{"url": "https://b.example"}], "confirmation_depth": 12}
+			]}`,
+			wantErr: "configured twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chains.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			registry, err := LoadChainRegistryFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadChainRegistryFile: %v", err)
+			}
+
+			chain, _ := registry.GetChain(1)
+			if got := chain.Endpoints[0]; got.URL != "https://node.example/secret" || got.Name != "endpoint-1" {
+				t.Errorf("endpoint = %+v, want the key expanded and a default name", got)
+			}
+		})
+	}
+}
+
+func TestApplyChainDefaults(t *testing.T) {
+	endpoints := []Endpoint{{URL: "https://a.example"}}
+
+	tests := []struct {
+		name    string
+		config  ChainConfig
+		wantErr string
+	}{
+		{name: "minimal", config: ChainConfig{ChainID: 5, Endpoints: endpoints, ConfirmationDepth: 1}},
+		{name: "finality tag alone", config: ChainConfig{ChainID: 5, Endpoints: endpoints, FinalityTag: BlockTagFinalized}},
+		{name: "zero chain ID", config: ChainConfig{Endpoints: endpoints, ConfirmationDepth: 1}, wantErr: "chain_id must be positive"},
+		{name: "unsupported type", config: ChainConfig{ChainID: 5, Type: "UTXO", Endpoints: endpoints, ConfirmationDepth: 1}, wantErr: "unsupported type"},
+		{name: "unknown finality tag", config: ChainConfig{ChainID: 5, Endpoints: endpoints, FinalityTag: "pending"}, wantErr: "unknown finality_tag"},
+		{name: "no endpoints", config: ChainConfig{ChainID: 5, ConfirmationDepth: 1}, wantErr: "at least one endpoint"},
+		{name: "endpoint without url", config: ChainConfig{ChainID: 5, Endpoints: []Endpoint{{Name: "a"}}, ConfirmationDepth: 1}, wantErr: "has no url"},
+		{name: "negative window", config: ChainConfig{ChainID: 5, Endpoints: endpoints, ConfirmationDepth: 1, ReorgWindow: -1}, wantErr: "must not be negative"},
+		{name: "depth 0 at latest", config: ChainConfig{ChainID: 5, Endpoints: endpoints, FinalityTag: BlockTagLatest}, wantErr: "confirmation_depth 0 requires"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Endpoints = append([]Endpoint(nil), tt.config.Endpoints...)
+
+			err := applyChainDefaults(&config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyChainDefaults: %v", err)
+			}
+
+			if config.Name != "Chain 5" || config.Type != ChainTypeEVM || config.Endpoints[0].Name != "endpoint-1" {
+				t.Errorf("config = %+v", config)
+			}
+			if config.NativeCurrency != (NativeCurrency{Symbol: "ETH", Decimals: 18}) {
+				t.Errorf("currency = %+v", config.NativeCurrency)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	registry, err := newRegistryFromConfigs([]*ChainConfig{{
+		ChainID: 1,
+		Endpoints: []Endpoint{
+			{Name: "mainnet", URL: chainIDStub(t, 1)},
+			{Name: "sepolia", URL: chainIDStub(t, 11155111)},
+			{Name: "down", URL: downStub(t).URL},
+		},
+		ConfirmationDepth: 12,
+	}})
+	if err != nil {
+		t.Fatalf("newRegistryFromConfigs: %v", err)
+	}
+
+	warnings, err := registry.Verify(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "endpoint sepolia reports chain ID 11155111") {
+		t.Errorf("Verify error = %v, want the sepolia endpoint refused", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "mainnet") {
+		t.Errorf("Verify error = %v, mentions the correct endpoint", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "endpoint down unreachable") {
+		t.Errorf("warnings = %v, want the unreachable endpoint only", warnings)
+	}
+}
@@ -0,0 +1,257 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Endpoint is one JSON-RPC provider for a chain
+type Endpoint struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// RateLimit overrides the chain-wide limit for this provider
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+}
+
+// endpoint tracks the health of a provider as seen by this process
+type endpoint struct {
+	Endpoint
+
+	mu                  sync.Mutex
+	latency             time.Duration // EWMA of successful request latency
+	errorRate           float64       // EWMA of failures, 0..1
+	head                int64         // Last block number reported by a probe
+	requests            int64
+	failures            int64
+	consecutiveFailures int
+	cooldownUntil       time.Time
+
+	limiter *rateLimiter
+}
+
+// ewmaWeight is how much a new sample moves the running averages
+const ewmaWeight = 0.2
+
+// record updates health after a request to this endpoint
+func (e *endpoint) record(err error, latency time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requests++
+
+	if err != nil {
+		e.failures++
+		e.consecutiveFailures++
+		e.errorRate = e.errorRate*(1-ewmaWeight) + ewmaWeight
+
+		// Back off from a failing provider: 1s, 2s, 4s... up to a minute
+		cooldown := time.Duration(math.Min(
+			float64(time.Second)*math.Pow(2, float64(e.consecutiveFailures-1)),
+			float64(time.Minute),
+		))
+		e.cooldownUntil = time.Now().Add(cooldown)
+		return
+	}
+
+	e.consecutiveFailures = 0
+	e.cooldownUntil = time.Time{}
+	e.errorRate = e.errorRate * (1 - ewmaWeight)
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(float64(e.latency)*(1-ewmaWeight) + float64(latency)*ewmaWeight)
+	}
+}
+
+// recordHead stores the block number an endpoint reported
+func (e *endpoint) recordHead(head int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.head = head
+}
+
+// EndpointHealth is a snapshot of one provider's health
+type EndpointHealth struct {
+	Name      string
+	Healthy   bool
+	Latency   time.Duration
+	ErrorRate float64
+	Head      int64
+	HeadLag   int64
+	Requests  int64
+	Failures  int64
+
+	Limiter LimiterStats
+}
+
+// snapshot computes health relative to the best head among all endpoints
+func (e *endpoint) snapshot(bestHead, maxHeadLag int64, now time.Time) EndpointHealth {
+	limiterStats := e.limiter.stats()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	h := EndpointHealth{
+		Name:      e.Name,
+		Latency:   e.latency,
+		ErrorRate: e.errorRate,
+		Head:      e.head,
+		Requests:  e.requests,
+		Failures:  e.failures,
+		Limiter:   limiterStats,
+	}
+
+	if e.head > 0 && bestHead > e.head {
+		h.HeadLag = bestHead - e.head
+	}
+
+	h.Healthy = !now.Before(e.cooldownUntil) &&
+		(maxHeadLag <= 0 || h.HeadLag <= maxHeadLag) &&
+		limiterStats.BudgetRemaining != 0
+	return h
+}
+
+// score ranks healthy endpoints, lower is better. Configuration order acts
+// as a tie-breaker so the primary provider wins when all else is equal.
+func (h EndpointHealth) score(priority int) float64 {
+	latencyMs := float64(h.Latency.Milliseconds())
+	if h.Latency == 0 {
+		latencyMs = 100 // Unknown until the first request
+	}
+	return latencyMs*(1+4*h.ErrorRate) + float64(h.HeadLag)*100 + float64(priority)*25
+}
+
+// rankEndpoints orders endpoints for the next request: healthy ones by score,
+// then unhealthy ones as a last resort
+func (c *RPCClient) rankEndpoints() []*endpoint {
+	if len(c.endpoints) == 1 {
+		return c.endpoints
+	}
+
+	health := c.health()
+
+	order := make([]int, len(c.endpoints))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		ha, hb := health[order[a]], health[order[b]]
+		if ha.Healthy != hb.Healthy {
+			return ha.Healthy
+		}
+		return ha.score(order[a]) < hb.score(order[b])
+	})
+
+	ranked := make([]*endpoint, len(order))
+	for i, idx := range order {
+		ranked[i] = c.endpoints[idx]
+	}
+	return ranked
+}
+
+// health returns a snapshot for every endpoint, in configuration order
+func (c *RPCClient) health() []EndpointHealth {
+	var bestHead int64
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		bestHead = max(bestHead, e.head)
+		e.mu.Unlock()
+	}
+
+	now := time.Now()
+	health := make([]EndpointHealth, len(c.endpoints))
+	for i, e := range c.endpoints {
+		health[i] = e.snapshot(bestHead, c.MaxHeadLag, now)
+	}
+	return health
+}
+
+// Health returns the current health of every endpoint, in configuration order
+func (c *RPCClient) Health() []EndpointHealth {
+	return c.health()
+}
+
+// ProbeHeads asks every endpoint for its latest block so lagging providers
+// can be ranked down. Errors count against the endpoint's health.
+func (c *RPCClient) ProbeHeads(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range c.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+
+			request := JSONRPCRequest{
+				JSONRPC: "2.0",
+				Method:  "eth_blockNumber",
+				Params:  []interface{}{},
+				ID:      1,
+			}
+
+			var response JSONRPCResponse
+			if err := c.postTo(ctx, e, request, &response); err != nil {
+				return
+			}
+			if response.Error != nil {
+				return
+			}
+
+			var result string
+			if err := json.Unmarshal(response.Result, &result); err != nil {
+				return
+			}
+			if head, err := HexToInt64(result); err == nil {
+				e.recordHead(head)
+			}
+		}(e)
+	}
+	wg.Wait()
+}
+
+// ProviderTrace records which endpoint served the requests made with a context
+type ProviderTrace struct {
+	mu   sync.Mutex
+	name string
+}
+
+type providerTraceKey struct{}
+
+// WithProviderTrace returns a context whose RPC calls record their provider on the trace
+func WithProviderTrace(ctx context.Context) (context.Context, *ProviderTrace) {
+	trace := &ProviderTrace{}
+	return context.WithValue(ctx, providerTraceKey{}, trace), trace
+}
+
+// Set overrides the recorded provider name
+func (t *ProviderTrace) Set(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.name = name
+}
+
+// Name returns the provider that served the most recent request
+func (t *ProviderTrace) Name() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.name
+}
+
+// ProviderFromContext returns the provider recorded on ctx's trace, if any
+func ProviderFromContext(ctx context.Context) string {
+	if trace, ok := ctx.Value(providerTraceKey{}).(*ProviderTrace); ok {
+		return trace.Name()
+	}
+	return ""
+}
+
+// recordProvider notes the endpoint that served a request on ctx's trace
+func recordProvider(ctx context.Context, name string) {
+	if trace, ok := ctx.Value(providerTraceKey{}).(*ProviderTrace); ok {
+		trace.Set(name)
+	}
+}
@@ -0,0 +1,231 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// headStub is a node reporting head as its latest block
+func headStub(t *testing.T, head int64) *httptest.Server {
+	return rpcStub(t, func(req JSONRPCRequest) *JSONRPCResponse {
+		return result(req, Int64ToHex(head))
+	})
+}
+
+// downStub is a node that answers everything with a 503
+func downStub(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFailoverOnServerError(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: downStub(t).URL},
+		{Name: "secondary", URL: headStub(t, 100).URL},
+	})
+
+	ctx, trace := WithProviderTrace(context.Background())
+	head, err := client.GetBlockNumber(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockNumber: %v", err)
+	}
+	if head != 100 {
+		t.Errorf("head = %d, want 100", head)
+	}
+	if got := trace.Name(); got != "secondary" {
+		t.Errorf("recorded provider = %q, want secondary", got)
+	}
+
+	health := client.Health()
+	if health[0].Failures != 1 || health[0].Healthy {
+		t.Errorf("primary health = %+v, want one failure and cooling down", health[0])
+	}
+	if health[1].Requests != 1 || health[1].Failures != 0 {
+		t.Errorf("secondary health = %+v", health[1])
+	}
+
+	// The failed primary is cooling down, so the secondary goes first now
+	if _, err := client.GetBlockNumber(ctx); err != nil {
+		t.Fatalf("GetBlockNumber: %v", err)
+	}
+	if health := client.Health(); health[0].Requests != 1 || health[1].Requests != 2 {
+		t.Errorf("requests = %d, %d; want the primary skipped", health[0].Requests, health[1].Requests)
+	}
+}
+
+func TestLaggingEndpointRankedDown(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: headStub(t, 100).URL},
+		{Name: "secondary", URL: headStub(t, 200).URL},
+	})
+	client.MaxHeadLag = 10
+
+	client.ProbeHeads(context.Background())
+
+	health := client.Health()
+	if health[0].Head != 100 || health[0].HeadLag != 100 || health[0].Healthy {
+		t.Errorf("primary health = %+v, want 100 blocks behind and unhealthy", health[0])
+	}
+	if health[1].Head != 200 || health[1].HeadLag != 0 || !health[1].Healthy {
+		t.Errorf("secondary health = %+v", health[1])
+	}
+
+	ctx, trace := WithProviderTrace(context.Background())
+	head, err := client.GetBlockNumber(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockNumber: %v", err)
+	}
+	if head != 200 || trace.Name() != "secondary" {
+		t.Errorf("served head %d by %q, want 200 by secondary", head, trace.Name())
+	}
+}
+
+func TestErrorRateRanksEndpointDown(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: headStub(t, 100).URL},
+		{Name: "secondary", URL: headStub(t, 100).URL},
+	})
+
+	// Once its cooldown is over, a failure still weighs on the primary's score
+	primary := client.endpoints[0]
+	primary.record(errors.New("timeout"), 0)
+	primary.cooldownUntil = time.Time{}
+
+	ranked := client.rankEndpoints()
+	if ranked[0].Name != "secondary" {
+		t.Errorf("ranked first = %s, want secondary", ranked[0].Name)
+	}
+}
+
+func TestAllEndpointsFail(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: downStub(t).URL},
+		{Name: "secondary", URL: downStub(t).URL},
+	})
+
+	ctx, trace := WithProviderTrace(context.Background())
+	_, err := client.GetBlockNumber(ctx)
+	if err == nil {
+		t.Fatal("GetBlockNumber succeeded with every endpoint down")
+	}
+
+	for _, name := range []string{"provider primary:", "provider secondary:"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %q", err, name)
+		}
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("error = %v, want the endpoints' HTTPErrors joined", err)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = false", err)
+	}
+	if trace.Name() != "" {
+		t.Errorf("recorded provider %q for a failed request", trace.Name())
+	}
+}
+
+// rateLimitedStub is a node that answers every call with a 200 carrying a
+// -32005 rate-limit error, or a single error object for a whole batch
+func rateLimitedStub(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32005,"message":"daily request count exceeded"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFailoverOnRetryableRPCError(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: rateLimitedStub(t).URL},
+		{Name: "secondary", URL: headStub(t, 100).URL},
+	})
+
+	ctx, trace := WithProviderTrace(context.Background())
+	head, err := client.GetBlockNumber(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockNumber: %v", err)
+	}
+	if head != 100 || trace.Name() != "secondary" {
+		t.Errorf("served head %d by %q, want 100 by secondary", head, trace.Name())
+	}
+
+	health := client.Health()
+	if health[0].Failures != 1 || health[0].Healthy {
+		t.Errorf("primary health = %+v, want one failure and cooling down", health[0])
+	}
+}
+
+func TestFailoverOnRejectedBatch(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: rateLimitedStub(t).URL},
+		{Name: "secondary", URL: headStub(t, 100).URL},
+	})
+
+	var a, b string
+	elems := []BatchElem{
+		{Method: "eth_blockNumber", Result: &a},
+		{Method: "eth_blockNumber", Result: &b},
+	}
+	if err := client.BatchCall(context.Background(), elems); err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+	if a != "0x64" || b != "0x64" {
+		t.Errorf("results = %q, %q; want both answered by the secondary", a, b)
+	}
+	if health := client.Health(); health[0].Failures != 1 {
+		t.Errorf("primary health = %+v, want the rejected batch counted", health[0])
+	}
+}
+
+func TestRevertDoesNotFailOver(t *testing.T) {
+	revert := func(req JSONRPCRequest) *JSONRPCResponse {
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: &RPCError{Code: -32000, Message: "execution reverted: not owner"}}
+	}
+	primary, secondary := rpcStub(t, revert), rpcStub(t, revert)
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: primary.URL},
+		{Name: "secondary", URL: secondary.URL},
+	})
+
+	_, err := client.Call(context.Background(), EthCallMsg{To: "0xto"}, "latest")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.RevertReason() != "not owner" {
+		t.Fatalf("Call error = %v, want the revert", err)
+	}
+
+	health := client.Health()
+	if health[0].Requests+health[1].Requests != 1 || health[0].Failures+health[1].Failures != 0 {
+		t.Errorf("health = %+v, want one clean request", health)
+	}
+}
+
+func TestAllEndpointsRateLimited(t *testing.T) {
+	client := NewFailoverRPCClient([]Endpoint{
+		{Name: "primary", URL: rateLimitedStub(t).URL},
+		{Name: "secondary", URL: rateLimitedStub(t).URL},
+	})
+
+	// The caller still gets the node's own error to classify
+	_, err := client.GetBlockNumber(context.Background())
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32005 || !IsRetryable(err) {
+		t.Errorf("error = %v, want the retryable -32005", err)
+	}
+}
@@ -0,0 +1,274 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned when an endpoint has spent its daily credit budget
+var ErrBudgetExhausted = errors.New("daily RPC credit budget exhausted")
+
+// BudgetReset returns when daily credit budgets next start over: the UTC
+// midnight after now
+func BudgetReset(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// defaultMethodCosts are per-call credit weights, modelled on provider
+// compute-unit pricing. Unknown methods cost defaultMethodCost.
+var defaultMethodCosts = map[string]int64{
+	"eth_chainId":               1,
+	"eth_blockNumber":           10,
+	"eth_getBlockByNumber":      16,
+	"eth_getTransactionByHash":  17,
+	"eth_getTransactionReceipt": 15,
+	"eth_call":                  26,
+}
+
+const defaultMethodCost = 20
+
+// MethodCost returns the default credit weight of one JSON-RPC call
+func MethodCost(method string) int64 {
+	if cost, ok := defaultMethodCosts[method]; ok {
+		return cost
+	}
+	return defaultMethodCost
+}
+
+// CreditLedger keeps each endpoint's daily credit spend where every replica
+// can see it, so a budget holds across horizontally scaled workers and
+// survives restarts. Without one, each process counts only its own spend.
+type CreditLedger interface {
+	// Spend adds credits to key's spend on day (a UTC date) unless the total
+	// would exceed budget, and returns the day's spend afterwards. ok is
+	// false when the credits were not charged. Negative credits refund.
+	Spend(ctx context.Context, key, day string, credits, budget int64) (spent int64, ok bool, err error)
+}
+
+// rateLimiter is a token bucket (one token per JSON-RPC call) with an
+// optional daily credit budget. A nil limiter never waits.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	rate   float64 // tokens per second, 0 = unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	dailyBudget int64 // 0 = unlimited
+	methodCosts map[string]int64
+	spent       int64  // today's spend: this process's, or the ledger's as last seen
+	day         string // UTC date the spent counter belongs to
+
+	// ledger, when set, is the source of truth for spend under key
+	ledger CreditLedger
+	key    string
+
+	waits     int64
+	waitTotal time.Duration
+
+	now func() time.Time
+}
+
+// newRateLimiter returns nil when cfg sets no limits
+func newRateLimiter(cfg *RateLimitConfig) *rateLimiter {
+	if cfg == nil || (cfg.RequestsPerSecond <= 0 && cfg.DailyCreditBudget <= 0) {
+		return nil
+	}
+
+	burst := float64(cfg.Burst)
+	if burst < 1 {
+		burst = max(1, cfg.RequestsPerSecond)
+	}
+
+	return &rateLimiter{
+		rate:        cfg.RequestsPerSecond,
+		burst:       burst,
+		tokens:      burst,
+		last:        time.Now(),
+		dailyBudget: cfg.DailyCreditBudget,
+		methodCosts: cfg.MethodCosts,
+		now:         time.Now,
+	}
+}
+
+// cost returns the credit weight of one call, honouring configured overrides
+func (l *rateLimiter) cost(method string) int64 {
+	if cost, ok := l.methodCosts[method]; ok {
+		return cost
+	}
+	return MethodCost(method)
+}
+
+// wait blocks until there are tokens for every call in body and charges its
+// credits against the daily budget. It fails fast with ErrBudgetExhausted so
+// the caller can fail over to another provider instead of waiting for midnight.
+func (l *rateLimiter) wait(ctx context.Context, body interface{}) error {
+	if l == nil {
+		return nil
+	}
+
+	calls, credits := l.requestCost(body)
+
+	day, viaLedger, err := l.charge(ctx, credits)
+	if err != nil {
+		return err
+	}
+
+	delay := l.reserve(calls)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Hand back what we reserved but never used
+		l.mu.Lock()
+		l.tokens += float64(calls)
+		l.mu.Unlock()
+		l.refund(context.WithoutCancel(ctx), day, viaLedger, credits)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token for every call and returns how long to wait before
+// sending them. A batch larger than the bucket drives it negative, so the
+// wait covers the whole batch at the configured rate.
+func (l *rateLimiter) reserve(calls int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+
+	now := l.now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(calls)
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.waits++
+	l.waitTotal += delay
+	return delay
+}
+
+// charge spends credits from today's budget, returning the day they were
+// charged to and whether the ledger took them. Spend goes through the ledger
+// when there is one; if it can't be reached, this process's own count is
+// enforced instead.
+func (l *rateLimiter) charge(ctx context.Context, credits int64) (day string, viaLedger bool, err error) {
+	if l.dailyBudget <= 0 {
+		return "", false, nil
+	}
+
+	l.mu.Lock()
+	day = l.now().UTC().Format(time.DateOnly)
+	if day != l.day {
+		l.day, l.spent = day, 0
+	}
+	l.mu.Unlock()
+
+	if l.ledger != nil {
+		spent, ok, err := l.ledger.Spend(ctx, l.key, day, credits, l.dailyBudget)
+		if err == nil {
+			l.mu.Lock()
+			if l.day == day {
+				l.spent = spent
+			}
+			l.mu.Unlock()
+
+			if !ok {
+				return day, false, ErrBudgetExhausted
+			}
+			return day, true, nil
+		}
+		log.Printf("⚠️  Credit ledger unavailable for %s, counting locally: %v", l.key, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.spent+credits > l.dailyBudget {
+		return day, false, ErrBudgetExhausted
+	}
+	l.spent += credits
+	return day, false, nil
+}
+
+// refund returns credits charged to day that were never used
+func (l *rateLimiter) refund(ctx context.Context, day string, viaLedger bool, credits int64) {
+	if l.dailyBudget <= 0 {
+		return
+	}
+
+	if viaLedger {
+		if _, _, err := l.ledger.Spend(ctx, l.key, day, -credits, l.dailyBudget); err != nil {
+			log.Printf("⚠️  Failed to refund %d credits to %s: %v", credits, l.key, err)
+		}
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.day == day {
+		l.spent = max(l.spent-credits, 0)
+	}
+}
+
+// LimiterStats reports how much an endpoint's limiter has slowed us down
+type LimiterStats struct {
+	Waits     int64
+	WaitTotal time.Duration
+
+	// BudgetRemaining is today's unspent credits, or -1 when unlimited
+	BudgetRemaining int64
+}
+
+// stats returns a snapshot of the limiter's counters
+func (l *rateLimiter) stats() LimiterStats {
+	if l == nil {
+		return LimiterStats{BudgetRemaining: -1}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := LimiterStats{Waits: l.waits, WaitTotal: l.waitTotal, BudgetRemaining: -1}
+	if l.dailyBudget > 0 {
+		spent := l.spent
+		if l.day != l.now().UTC().Format(time.DateOnly) {
+			spent = 0
+		}
+		s.BudgetRemaining = l.dailyBudget - spent
+	}
+	return s
+}
+
+// requestCost counts the calls and credits in a single or batch request
+func (l *rateLimiter) requestCost(body interface{}) (int, int64) {
+	switch req := body.(type) {
+	case JSONRPCRequest:
+		return 1, l.cost(req.Method)
+	case []JSONRPCRequest:
+		var credits int64
+		for _, r := range req {
+			credits += l.cost(r.Method)
+		}
+		return len(req), credits
+	default:
+		return 1, defaultMethodCost
+	}
+}
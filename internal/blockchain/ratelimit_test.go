@@ -0,0 +1,182 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock the test moves by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// testLimiter returns a limiter running on clock
+func testLimiter(cfg RateLimitConfig, clock *fakeClock) *rateLimiter {
+	l := newRateLimiter(&cfg)
+	l.now = clock.Now
+	l.last = clock.Now()
+	return l
+}
+
+// memLedger is a CreditLedger shared by limiters standing in for replicas
+type memLedger struct {
+	mu    sync.Mutex
+	spent map[string]int64
+	err   error
+}
+
+func (m *memLedger) Spend(ctx context.Context, key, day string, credits, budget int64) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return 0, false, m.err
+	}
+	k := key + "@" + day
+	if m.spent[k]+credits > budget {
+		return m.spent[k], false, nil
+	}
+	m.spent[k] = max(m.spent[k]+credits, 0)
+	return m.spent[k], true, nil
+}
+
+func batch(method string, n int) []JSONRPCRequest {
+	reqs := make([]JSONRPCRequest, n)
+	for i := range reqs {
+		reqs[i] = JSONRPCRequest{Method: method, ID: i + 1}
+	}
+	return reqs
+}
+
+func TestTokenBucketChargesEveryCall(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := testLimiter(RateLimitConfig{RequestsPerSecond: 10, Burst: 20}, clock)
+
+	// A full bucket covers a batch up to the burst size
+	if delay := l.reserve(20); delay != 0 {
+		t.Errorf("reserve(20) on a full bucket = %v, want 0", delay)
+	}
+	if delay := l.reserve(1); delay != 100*time.Millisecond {
+		t.Errorf("reserve(1) on an empty bucket = %v, want 100ms", delay)
+	}
+
+	// A batch larger than the burst waits for every one of its calls
+	clock.Advance(10 * time.Second)
+	if delay := l.reserve(50); delay != 3*time.Second {
+		t.Errorf("reserve(50) = %v, want 3s for the 30 calls beyond the burst", delay)
+	}
+	clock.Advance(3 * time.Second)
+	if delay := l.reserve(10); delay != time.Second {
+		t.Errorf("reserve(10) after the batch's wait = %v, want 1s", delay)
+	}
+
+	if s := l.stats(); s.Waits != 3 || s.WaitTotal != 4100*time.Millisecond {
+		t.Errorf("stats = %+v, want 3 waits totalling 4.1s", s)
+	}
+}
+
+func TestTokenBucketRefillsUpToBurst(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := testLimiter(RateLimitConfig{RequestsPerSecond: 5, Burst: 10}, clock)
+
+	l.reserve(10)
+	clock.Advance(time.Hour)
+	if delay := l.reserve(10); delay != 0 {
+		t.Errorf("reserve(10) after an idle hour = %v, want 0", delay)
+	}
+	if delay := l.reserve(1); delay != 200*time.Millisecond {
+		t.Errorf("reserve(1) = %v, want 200ms: idle time must not bank more than the burst", delay)
+	}
+}
+
+func TestDailyBudgetResetsAtUTCMidnight(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)}
+	l := testLimiter(RateLimitConfig{DailyCreditBudget: 100}, clock)
+
+	if err := l.wait(ctx, batch("eth_x", 3)); err != nil { // 60 credits
+		t.Fatalf("wait: %v", err)
+	}
+	if err := l.wait(ctx, batch("eth_getTransactionByHash", 3)); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("wait over budget error = %v, want ErrBudgetExhausted", err)
+	}
+	if got := l.stats().BudgetRemaining; got != 40 {
+		t.Errorf("BudgetRemaining = %d, want 40", got)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if got := l.stats().BudgetRemaining; got != 100 {
+		t.Errorf("BudgetRemaining after midnight = %d, want 100", got)
+	}
+	if err := l.wait(ctx, batch("eth_x", 5)); err != nil {
+		t.Errorf("wait after midnight: %v", err)
+	}
+}
+
+func TestDailyBudgetSharedThroughLedger(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	ledger := &memLedger{spent: make(map[string]int64)}
+
+	replicas := make([]*rateLimiter, 2)
+	for i := range replicas {
+		replicas[i] = testLimiter(RateLimitConfig{DailyCreditBudget: 100}, clock)
+		replicas[i].ledger, replicas[i].key = ledger, "1/infura"
+	}
+
+	if err := replicas[0].wait(ctx, batch("eth_x", 3)); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if err := replicas[1].wait(ctx, batch("eth_x", 3)); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("second replica over the shared budget error = %v, want ErrBudgetExhausted", err)
+	}
+	if got := replicas[1].stats().BudgetRemaining; got != 40 {
+		t.Errorf("second replica BudgetRemaining = %d, want the shared 40", got)
+	}
+
+	// A restarted replica starts from the recorded spend, not from zero
+	restarted := testLimiter(RateLimitConfig{DailyCreditBudget: 100}, clock)
+	restarted.ledger, restarted.key = ledger, "1/infura"
+	if err := restarted.wait(ctx, batch("eth_x", 3)); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("restarted replica error = %v, want ErrBudgetExhausted", err)
+	}
+
+	// Without the ledger, a replica keeps counting from the spend it last saw
+	ledger.err = errors.New("connection refused")
+	if err := replicas[1].wait(ctx, batch("eth_x", 2)); err != nil {
+		t.Errorf("wait with the ledger down: %v", err)
+	}
+	if err := replicas[1].wait(ctx, batch("eth_x", 1)); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("wait over the local budget error = %v, want ErrBudgetExhausted", err)
+	}
+}
+
+func TestWaitRefundsWhenCancelled(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	ledger := &memLedger{spent: make(map[string]int64)}
+	l := testLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 1, DailyCreditBudget: 100}, clock)
+	l.ledger, l.key = ledger, "1/infura"
+
+	if err := l.wait(context.Background(), batch("eth_x", 1)); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, batch("eth_x", 2)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait with a cancelled context error = %v, want context.Canceled", err)
+	}
+
+	if got := ledger.spent["1/infura@2026-01-01"]; got != 20 {
+		t.Errorf("ledger spend = %d, want 20 after the refund", got)
+	}
+	if delay := l.reserve(0); delay != 0 || l.tokens != 0 {
+		t.Errorf("tokens after refund = %v, want the cancelled calls handed back", l.tokens)
+	}
+}
@@ -1,15 +1,62 @@
 package blockchain
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 )
 
+// ErrUnsupportedChain is returned for chain IDs that are not registered
+var ErrUnsupportedChain = errors.New("unsupported chain ID")
+
 // ChainConfig holds configuration for a blockchain network
 type ChainConfig struct {
-	ChainID int
-	Name    string
-	RPCURL  string
-	Type    ChainType
+	ChainID int       `json:"chain_id"`
+	Name    string    `json:"name"`
+	Type    ChainType `json:"type"`
+
+	// Endpoints are the chain's RPC providers in order of preference
+	Endpoints []Endpoint `json:"endpoints"`
+
+	// MaxHeadLag is how many blocks a provider may fall behind the others
+	// before requests fail over away from it
+	MaxHeadLag int64 `json:"max_head_lag"`
+
+	// ConfirmationDepth is the number of blocks that must be built on top of
+	// a transaction's block before it is considered CONFIRMED
+	ConfirmationDepth int64 `json:"confirmation_depth"`
+
+	// FinalityTag additionally requires the block to be at or below the
+	// node's safe/finalized block. Empty means depth alone decides.
+	FinalityTag BlockTag `json:"finality_tag"`
+
+	// ReorgWindow is how many recent blocks the reorg watcher re-verifies
+	ReorgWindow int64 `json:"reorg_window"`
+
+	// RateLimit applies to each endpoint unless the endpoint sets its own
+	RateLimit RateLimitConfig `json:"rate_limit"`
+
+	// NativeCurrency describes the coin the value column is denominated in
+	NativeCurrency NativeCurrency `json:"native_currency"`
+}
+
+// RateLimitConfig bounds how hard we lean on an RPC provider
+type RateLimitConfig struct {
+	// RequestsPerSecond and Burst are enforced by each process on its own
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+	// DailyCreditBudget caps provider credits spent per UTC day (0 = unlimited).
+	// With a CreditLedger it is shared by every replica; without one it is per process.
+	DailyCreditBudget int64 `json:"daily_credit_budget"`
+	// MethodCosts overrides the default credit weight of individual methods
+	MethodCosts map[string]int64 `json:"method_costs,omitempty"`
+}
+
+// NativeCurrency is a chain's base coin, e.g. ETH with 18 decimals
+type NativeCurrency struct {
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
 }
 
 // ChainType represents the blockchain type
@@ -22,37 +69,59 @@ const (
 // ChainRegistry manages blockchain configurations
 type ChainRegistry struct {
 	chains map[int]*ChainConfig
+
+	// One client per chain so provider health is shared by every caller
+	mu      sync.Mutex
+	clients map[int]*RPCClient
+
+	// ledger, when set, shares daily credit spend between processes
+	ledger CreditLedger
 }
 
-// NewChainRegistry creates a new chain registry with default configurations
-func NewChainRegistry(infuraAPIKey string) *ChainRegistry {
+// NewChainRegistry creates a new chain registry with default configurations.
+// Fallback URLs are tried, in order, when Infura errors or falls behind.
+func NewChainRegistry(infuraAPIKey string, fallbackURLs ...string) *ChainRegistry {
 	registry := &ChainRegistry{
-		chains: make(map[int]*ChainConfig),
+		chains:  make(map[int]*ChainConfig),
+		clients: make(map[int]*RPCClient),
+	}
+
+	endpoints := []Endpoint{
+		{Name: "infura", URL: fmt.Sprintf("https://mainnet.infura.io/v3/%s", infuraAPIKey)},
+	}
+	for i, url := range fallbackURLs {
+		endpoints = append(endpoints, Endpoint{Name: fmt.Sprintf("fallback-%d", i+1), URL: url})
 	}
 
 	// Ethereum Mainnet
 	registry.RegisterChain(&ChainConfig{
-		ChainID: 1,
-		Name:    "Ethereum Mainnet",
-		RPCURL:  fmt.Sprintf("https://mainnet.infura.io/v3/%s", infuraAPIKey),
-		Type:    ChainTypeEVM,
+		ChainID:    1,
+		Name:       "Ethereum Mainnet",
+		Type:       ChainTypeEVM,
+		Endpoints:  endpoints,
+		MaxHeadLag: defaultMaxHeadLag,
+
+		ConfirmationDepth: defaultConfirmationDepth,
+		FinalityTag:       BlockTagFinalized,
+		ReorgWindow:       defaultReorgWindow,
+		NativeCurrency:    NativeCurrency{Symbol: "ETH", Decimals: 18},
 	})
 
 	// Future: Add more chains here
 	// Polygon
 	// registry.RegisterChain(&ChainConfig{
-	//     ChainID: 137,
-	//     Name:    "Polygon",
-	//     RPCURL:  "https://polygon-rpc.com",
-	//     Type:    ChainTypeEVM,
+	//     ChainID:   137,
+	//     Name:      "Polygon",
+	//     Endpoints: []Endpoint{{Name: "polygon-rpc", URL: "https://polygon-rpc.com"}},
+	//     Type:      ChainTypeEVM,
 	// })
 	//
 	// Arbitrum
 	// registry.RegisterChain(&ChainConfig{
-	//     ChainID: 42161,
-	//     Name:    "Arbitrum One",
-	//     RPCURL:  "https://arb1.arbitrum.io/rpc",
-	//     Type:    ChainTypeEVM,
+	//     ChainID:   42161,
+	//     Name:      "Arbitrum One",
+	//     Endpoints: []Endpoint{{Name: "arbitrum", URL: "https://arb1.arbitrum.io/rpc"}},
+	//     Type:      ChainTypeEVM,
 	// })
 
 	return registry
@@ -60,29 +129,79 @@ func NewChainRegistry(infuraAPIKey string) *ChainRegistry {
 
 // RegisterChain adds a chain configuration to the registry
 func (r *ChainRegistry) RegisterChain(config *ChainConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.chains[config.ChainID] = config
+	delete(r.clients, config.ChainID)
+}
+
+// UseCreditLedger makes every endpoint's daily credit budget count the spend
+// recorded in ledger, i.e. by all replicas, rather than this process's alone
+func (r *ChainRegistry) UseCreditLedger(ledger CreditLedger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ledger = ledger
+	clear(r.clients)
 }
 
 // GetChain returns the configuration for a chain ID
 func (r *ChainRegistry) GetChain(chainID int) (*ChainConfig, error) {
 	config, ok := r.chains[chainID]
 	if !ok {
-		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedChain, chainID)
 	}
 	return config, nil
 }
 
+// Client returns the shared failover RPC client for a chain
+func (r *ChainRegistry) Client(chainID int) (*RPCClient, error) {
+	config, err := r.GetChain(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[chainID]
+	if !ok {
+		// Endpoints without their own limit share the chain-wide one
+		endpoints := make([]Endpoint, len(config.Endpoints))
+		for i, e := range config.Endpoints {
+			if e.RateLimit == nil {
+				limit := config.RateLimit
+				e.RateLimit = &limit
+			}
+			endpoints[i] = e
+		}
+
+		client = NewFailoverRPCClient(endpoints)
+		client.MaxHeadLag = config.MaxHeadLag
+		for _, e := range client.endpoints {
+			if e.limiter != nil {
+				e.limiter.ledger = r.ledger
+				e.limiter.key = fmt.Sprintf("%d/%s", chainID, e.Name)
+			}
+		}
+		r.clients[chainID] = client
+	}
+	return client, nil
+}
+
 // IsSupported checks if a chain ID is supported
 func (r *ChainRegistry) IsSupported(chainID int) bool {
 	_, ok := r.chains[chainID]
 	return ok
 }
 
-// GetSupportedChains returns a list of all supported chain IDs
+// GetSupportedChains returns a sorted list of all supported chain IDs
 func (r *ChainRegistry) GetSupportedChains() []int {
 	chains := make([]int, 0, len(r.chains))
 	for chainID := range r.chains {
 		chains = append(chains, chainID)
 	}
+	slices.Sort(chains)
 	return chains
 }
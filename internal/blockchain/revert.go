@@ -0,0 +1,126 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ABI selectors for the built-in Solidity revert payloads
+const (
+	errorStringSelector = "08c379a0" // Error(string)
+	panicSelector       = "4e487b71" // Panic(uint256)
+)
+
+// panicReasons maps Solidity panic codes to human readable descriptions
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized function",
+}
+
+// RevertReason extracts a revert reason from an eth_call error.
+// It prefers the ABI-encoded data field and falls back to the node's message.
+func (e *RPCError) RevertReason() string {
+	if data := revertData(e.Data); data != "" {
+		if reason, err := DecodeRevertReason(data); err == nil && reason != "" {
+			return reason
+		}
+	}
+
+	if reason, ok := strings.CutPrefix(e.Message, "execution reverted: "); ok {
+		return reason
+	}
+
+	if e.Message == "execution reverted" {
+		return ""
+	}
+
+	return e.Message
+}
+
+// revertData pulls the hex payload out of an error's data field. Geth and
+// most nodes send the hex string itself, OpenEthereum prefixes it with
+// "Reverted " and Hardhat nests it in an object.
+func revertData(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var data string
+	if err := json.Unmarshal(raw, &data); err == nil {
+		return strings.TrimPrefix(data, "Reverted ")
+	}
+
+	var nested struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &nested); err == nil {
+		return nested.Data
+	}
+	return ""
+}
+
+// isRevert reports whether the error is an eth_call reverting, which nodes
+// report with revert data or an "execution reverted" message
+func (e *RPCError) isRevert() bool {
+	return len(e.Data) > 0 || strings.HasPrefix(e.Message, "execution reverted")
+}
+
+// DecodeRevertReason decodes Error(string) and Panic(uint256) revert payloads.
+// Custom errors are returned as their raw selector since we have no ABI for them.
+func DecodeRevertReason(hexData string) (string, error) {
+	hexData = strings.TrimPrefix(hexData, "0x")
+	if hexData == "" {
+		return "", nil
+	}
+
+	data, err := hex.DecodeString(hexData)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode revert data: %w", err)
+	}
+
+	if len(data) < 4 {
+		return "", fmt.Errorf("revert data too short: %d bytes", len(data))
+	}
+
+	selector := hex.EncodeToString(data[:4])
+	payload := data[4:]
+
+	switch selector {
+	case errorStringSelector:
+		// offset (32) + length (32) + string bytes
+		if len(payload) < 64 {
+			return "", fmt.Errorf("malformed Error(string) payload")
+		}
+		length := new(big.Int).SetBytes(payload[32:64])
+		if !length.IsUint64() || length.Uint64() > uint64(len(payload)-64) {
+			return "", fmt.Errorf("malformed Error(string) length")
+		}
+		return string(payload[64 : 64+length.Uint64()]), nil
+
+	case panicSelector:
+		if len(payload) < 32 {
+			return "", fmt.Errorf("malformed Panic(uint256) payload")
+		}
+		code := new(big.Int).SetBytes(payload[:32])
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				return fmt.Sprintf("panic: %s (0x%x)", reason, code.Uint64()), nil
+			}
+		}
+		return fmt.Sprintf("panic: code 0x%s", code.Text(16)), nil
+
+	default:
+		return fmt.Sprintf("custom error 0x%s", selector), nil
+	}
+}
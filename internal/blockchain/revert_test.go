@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// word left-pads n to a 32-byte ABI word
+func word(n uint64) string {
+	return fmt.Sprintf("%064x", n)
+}
+
+// errorString ABI-encodes Error(reason)
+func errorString(reason string) string {
+	padded := hex.EncodeToString([]byte(reason))
+	if rem := len(padded) % 64; rem != 0 {
+		padded += strings.Repeat("0", 64-rem)
+	}
+	return "0x" + errorStringSelector + word(32) + word(uint64(len(reason))) + padded
+}
+
+func TestDecodeRevertReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", data: "", want: ""},
+		{name: "bare prefix", data: "0x", want: ""},
+		{name: "error string", data: errorString("not owner"), want: "not owner"},
+		{name: "error string without prefix", data: strings.TrimPrefix(errorString("paused"), "0x"), want: "paused"},
+		{name: "empty error string", data: errorString(""), want: ""},
+		{name: "known panic", data: "0x" + panicSelector + word(0x11), want: "panic: arithmetic overflow or underflow (0x11)"},
+		{name: "assert panic", data: "0x" + panicSelector + word(0x01), want: "panic: assertion failed (0x1)"},
+		{name: "unknown panic", data: "0x" + panicSelector + word(0x99), want: "panic: code 0x99"},
+		{name: "custom error", data: "0xfb8f41b2" + word(1), want: "custom error 0xfb8f41b2"},
+		{name: "custom error without arguments", data: "0x82b42900", want: "custom error 0x82b42900"},
+		{name: "not hex", data: "0xzz", wantErr: true},
+		{name: "shorter than a selector", data: "0x08c3", wantErr: true},
+		{name: "truncated error string", data: "0x" + errorStringSelector + word(32), wantErr: true},
+		{name: "error string longer than its data", data: "0x" + errorStringSelector + word(32) + word(100) + word(0), wantErr: true},
+		{name: "truncated panic", data: "0x" + panicSelector + "11", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRevertReason(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DecodeRevertReason(%q) = %q, want an error", tt.data, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeRevertReason(%q): %v", tt.data, err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeRevertReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRPCErrorRevertReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		data    string // raw JSON, empty for none
+		want    string
+	}{
+		{
+			name:    "geth hex data",
+			message: "execution reverted: not owner",
+			data:    `"` + errorString("not owner") + `"`,
+			want:    "not owner",
+		},
+		{
+			name:    "openethereum prefixed data",
+			message: "VM execution error.",
+			data:    `"Reverted ` + errorString("paused") + `"`,
+			want:    "paused",
+		},
+		{
+			name:    "hardhat nested data",
+			message: "Error: VM Exception while processing transaction",
+			data:    `{"message": "revert", "data": "` + errorString("too late") + `"}`,
+			want:    "too late",
+		},
+		{
+			name:    "panic data",
+			message: "execution reverted",
+			data:    `"0x` + panicSelector + word(0x12) + `"`,
+			want:    "panic: division or modulo by zero (0x12)",
+		},
+		{
+			name:    "custom error data",
+			message: "execution reverted",
+			data:    `"0x82b42900"`,
+			want:    "custom error 0x82b42900",
+		},
+		{
+			name:    "reason in the message only",
+			message: "execution reverted: insufficient balance",
+			want:    "insufficient balance",
+		},
+		{
+			name:    "bare revert",
+			message: "execution reverted",
+			want:    "",
+		},
+		{
+			name:    "garbage data falls back to the message",
+			message: "execution reverted: from message",
+			data:    `"0x1234"`,
+			want:    "from message",
+		},
+		{
+			name:    "unexpected data shape falls back to the message",
+			message: "execution reverted: from message",
+			data:    `[1, 2]`,
+			want:    "from message",
+		},
+		{
+			name:    "not a revert",
+			message: "out of gas",
+			want:    "out of gas",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpcErr := &RPCError{Code: 3, Message: tt.message}
+			if tt.data != "" {
+				rpcErr.Data = json.RawMessage(tt.data)
+			}
+			if got := rpcErr.RevertReason(); got != tt.want {
+				t.Errorf("RevertReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplayBlock(t *testing.T) {
+	tests := []struct {
+		blockNumber string
+		want        string
+	}{
+		{blockNumber: "0x64", want: "0x63"},
+		{blockNumber: "0x1", want: "0x0"},
+		{blockNumber: "0x0", want: "latest"},
+		{blockNumber: "", want: "latest"},
+		{blockNumber: "pending", want: "latest"},
+	}
+
+	for _, tt := range tests {
+		if got := replayBlock(tt.blockNumber); got != tt.want {
+			t.Errorf("replayBlock(%q) = %q, want %q", tt.blockNumber, got, tt.want)
+		}
+	}
+}
+
+func TestGetRevertReasonReplaysBeforeItsBlock(t *testing.T) {
+	var replayedAt string
+	server := rpcStub(t, func(req JSONRPCRequest) *JSONRPCResponse {
+		replayedAt, _ = req.Params[1].(string)
+		data, _ := json.Marshal(errorString("not owner"))
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: &RPCError{Code: 3, Message: "execution reverted: not owner", Data: data}}
+	})
+	client := NewFailoverRPCClient([]Endpoint{{Name: "stub", URL: server.URL}})
+
+	reason, err := client.GetRevertReason(context.Background(), &EthTransaction{From: "0xfrom", To: "0xto", BlockNumber: "0x64"})
+	if err != nil {
+		t.Fatalf("GetRevertReason: %v", err)
+	}
+	if reason != "not owner" || replayedAt != "0x63" {
+		t.Errorf("reason %q replayed at %s, want \"not owner\" at 0x63", reason, replayedAt)
+	}
+}
@@ -47,3 +47,7 @@ func HexToDecimalString(hexStr string) (string, error) {
 	}
 	return bigInt.String(), nil
 }
+
+func Int64ToHex(value int64) string {
+	return fmt.Sprintf("0x%x", value)
+}
@@ -0,0 +1,51 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidHash is returned for transaction hashes that are malformed for their chain
+var ErrInvalidHash = errors.New("invalid transaction hash")
+
+// NormalizeHash validates a transaction hash for the chain's type and returns
+// its canonical form, so submissions that differ only in case dedupe
+func (c *ChainConfig) NormalizeHash(hash string) (string, error) {
+	switch c.Type {
+	case ChainTypeEVM:
+		return normalizeEVMHash(hash)
+	default:
+		return "", fmt.Errorf("no hash format known for chain type %q", c.Type)
+	}
+}
+
+// NormalizeHash validates a hash against the given chain's format
+func (r *ChainRegistry) NormalizeHash(chainID int, hash string) (string, error) {
+	config, err := r.GetChain(chainID)
+	if err != nil {
+		return "", err
+	}
+	return config.NormalizeHash(hash)
+}
+
+// normalizeEVMHash accepts 0x plus 64 hex characters in any case and lowercases it
+func normalizeEVMHash(hash string) (string, error) {
+	hash = strings.TrimSpace(hash)
+
+	if len(hash) != 66 || (hash[:2] != "0x" && hash[:2] != "0X") {
+		return "", fmt.Errorf("%w: expected 0x followed by 64 hex characters", ErrInvalidHash)
+	}
+
+	for _, ch := range hash[2:] {
+		if !isHexDigit(ch) {
+			return "", fmt.Errorf("%w: non-hex character %q", ErrInvalidHash, ch)
+		}
+	}
+
+	return strings.ToLower(hash), nil
+}
+
+func isHexDigit(ch rune) bool {
+	return ('0' <= ch && ch <= '9') || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
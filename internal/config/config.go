@@ -1,18 +1,87 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
-	DatabaseURL   string
-	Port          string
-	InfuraAPIKey  string
+	DatabaseURL  string
+	Port         string
+	InfuraAPIKey string
+
+	// AutoMigrate applies pending migrations at startup; otherwise services
+	// refuse to start until the schema matches
+	AutoMigrate bool
+
+	// Largest POST /transactions/batch the API accepts
+	BatchMaxItems int
+
+	// Extra Ethereum mainnet providers, used when Infura errors or lags
+	EthereumFallbackRPCURLs []string
+
+	// ChainsConfigPath points at a JSON chain registry file. When unset,
+	// chains come from CHAIN_<ID>_* variables or the Infura default.
+	ChainsConfigPath string
+
+	// Worker claiming and concurrency
+	WorkerID          string
+	WorkerBatchSize   int
+	WorkerConcurrency int
+	WorkerLease       time.Duration
+	ReapInterval      time.Duration
+
+	// Port the worker serves live provider usage on (GET /providers)
+	WorkerStatusPort string
+
+	// Retry policy for failed fetches
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+
+	// PENDING re-check schedule
+	PendingRecheckBase time.Duration
+	PendingRecheckMax  time.Duration
+	PendingDropAfter   time.Duration
+
+	// How often recent blocks are re-verified against the canonical chain
+	ReorgCheckInterval time.Duration
+
+	// Webhook delivery retries and receiver timeout
+	WebhookMaxAttempts int
+	WebhookBaseDelay   time.Duration
+	WebhookMaxDelay    time.Duration
+	WebhookTimeout     time.Duration
 }
 
 func Load() Config {
 	return Config{
-		DatabaseURL:  getEnv("DATABASE_URL", "postgres://localhost/txnflow?sslmode=disable"),
-		Port:         getEnv("PORT", "8080"),
-		InfuraAPIKey: getEnv("INFURA_API_KEY", ""),
+		DatabaseURL:             getEnv("DATABASE_URL", "postgres://localhost/txnflow?sslmode=disable"),
+		Port:                    getEnv("PORT", "8080"),
+		AutoMigrate:             getEnvBool("AUTO_MIGRATE", false),
+		BatchMaxItems:           getEnvInt("BATCH_MAX_ITEMS", 1000),
+		InfuraAPIKey:            getEnv("INFURA_API_KEY", ""),
+		EthereumFallbackRPCURLs: getEnvList("ETHEREUM_FALLBACK_RPC_URLS"),
+		ChainsConfigPath:        getEnv("CHAINS_CONFIG", ""),
+		WorkerID:                getEnv("WORKER_ID", ""),
+		WorkerBatchSize:         getEnvInt("WORKER_BATCH_SIZE", 10),
+		WorkerConcurrency:       getEnvInt("WORKER_CONCURRENCY", 4),
+		WorkerLease:             getEnvDuration("WORKER_LEASE", 2*time.Minute),
+		ReapInterval:            getEnvDuration("REAP_INTERVAL", 30*time.Second),
+		WorkerStatusPort:        getEnv("WORKER_STATUS_PORT", "9090"),
+		RetryMaxAttempts:        getEnvInt("RETRY_MAX_ATTEMPTS", 5),
+		RetryBaseDelay:          getEnvDuration("RETRY_BASE_DELAY", 10*time.Second),
+		RetryMaxDelay:           getEnvDuration("RETRY_MAX_DELAY", 10*time.Minute),
+		PendingRecheckBase:      getEnvDuration("PENDING_RECHECK_BASE", 15*time.Second),
+		PendingRecheckMax:       getEnvDuration("PENDING_RECHECK_MAX", 5*time.Minute),
+		PendingDropAfter:        getEnvDuration("PENDING_DROP_AFTER", 30*time.Minute),
+		ReorgCheckInterval:      getEnvDuration("REORG_CHECK_INTERVAL", 30*time.Second),
+		WebhookMaxAttempts:      getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBaseDelay:        getEnvDuration("WEBHOOK_BASE_DELAY", 30*time.Second),
+		WebhookMaxDelay:         getEnvDuration("WEBHOOK_MAX_DELAY", time.Hour),
+		WebhookTimeout:          getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
 	}
 }
 
@@ -22,4 +91,44 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
-	
\ No newline at end of file
+
+// getEnvBool parses values like "true" or "1", ignoring malformed input
+func getEnvBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+// getEnvDuration parses values like "30s" or "5m", ignoring malformed input
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// getEnvInt parses positive integers, ignoring malformed input
+func getEnvInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated value, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrCommandUsage means the migrate arguments named no known subcommand
+var ErrCommandUsage = errors.New("usage: migrate up | down [-steps N] | status")
+
+// RunCommand runs a migrate subcommand ("up", "down [-steps N]" or "status")
+// and reports to out. It backs both cmd/migrate and txnflowctl migrate.
+func RunCommand(ctx context.Context, m *Migrator, args []string, out io.Writer, asJSON bool) error {
+	if len(args) == 0 {
+		return ErrCommandUsage
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(out, "applied %s\n", mig)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema up to date (%d applied)\n", len(applied))
+		return nil
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		fs.SetOutput(out)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 {
+			return ErrCommandUsage
+		}
+
+		reverted, err := m.Down(ctx, *steps)
+		for _, mig := range reverted {
+			fmt.Fprintf(out, "reverted %s\n", mig)
+		}
+		return err
+
+	case "status":
+		states, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return writeStates(out, states, asJSON)
+
+	default:
+		return ErrCommandUsage
+	}
+}
+
+// writeStates prints migration states as a table, or as JSON
+func writeStates(out io.Writer, states []MigrationState, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(states)
+	}
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "MIGRATION\tSTATE\tAPPLIED")
+	for _, s := range states {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		switch {
+		case s.Modified:
+			state = "modified since applied"
+		case s.Unknown:
+			state = "not in this build"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", s.Migration, state, appliedAt)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommandUsage(t *testing.T) {
+	// Each of these is refused before the migrator is touched
+	for _, args := range [][]string{nil, {"sideways"}, {"down", "-steps"}, {"down", "extra"}} {
+		var out bytes.Buffer
+		if err := RunCommand(context.Background(), nil, args, &out, false); !errors.Is(err, ErrCommandUsage) {
+			t.Errorf("RunCommand(%q) = %v, want ErrCommandUsage", args, err)
+		}
+	}
+}
+
+func TestWriteStates(t *testing.T) {
+	appliedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	all := []MigrationState{
+		{Migration: Migration{Version: 1, Name: "init"}, Applied: true, AppliedAt: &appliedAt},
+		{Migration: Migration{Version: 2, Name: "edited"}, Applied: true, AppliedAt: &appliedAt, Modified: true},
+		{Migration: Migration{Version: 3, Name: "next"}},
+		{Migration: Migration{Version: 4, Name: "newer"}, Applied: true, AppliedAt: &appliedAt, Unknown: true},
+	}
+
+	var table bytes.Buffer
+	if err := writeStates(&table, all, false); err != nil {
+		t.Fatalf("writeStates: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	want := []string{
+		"MIGRATION  STATE                   APPLIED",
+		"001_init   applied                 2024-05-01T12:00:00Z",
+		"002_edited modified since applied  2024-05-01T12:00:00Z",
+		"003_next   pending                 -",
+		"004_newer  not in this build       2024-05-01T12:00:00Z",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("table =\n%s", table.String())
+	}
+	for i := range want {
+		if strings.Join(strings.Fields(lines[i]), " ") != strings.Join(strings.Fields(want[i]), " ") {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeStates(&out, all, true); err != nil {
+		t.Fatalf("writeStates json: %v", err)
+	}
+	var decoded []MigrationState
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	if len(decoded) != 4 || decoded[2].Applied || !decoded[1].Modified || decoded[0].Name != "init" {
+		t.Errorf("json = %+v", decoded)
+	}
+}
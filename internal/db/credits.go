@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CreditLedger records daily RPC credit spend in rpc_credit_spend, so every
+// worker replica charges the same per-endpoint budget
+type CreditLedger struct {
+	DB *pgxpool.Pool
+}
+
+func NewCreditLedger(pool *pgxpool.Pool) *CreditLedger {
+	return &CreditLedger{DB: pool}
+}
+
+// Spend atomically adds credits to the endpoint's spend for day unless that
+// would take it over budget. The returned spend is the day's total after the
+// call; ok is false when nothing was charged. Negative credits refund.
+func (l *CreditLedger) Spend(ctx context.Context, endpoint, day string, credits, budget int64) (int64, bool, error) {
+	query := `
+		INSERT INTO rpc_credit_spend AS s (endpoint, day, credits)
+		SELECT $1::text, $2::date, GREATEST($3::bigint, 0)
+		WHERE $3::bigint <= $4::bigint
+		ON CONFLICT (endpoint, day) DO UPDATE
+		SET credits = GREATEST(s.credits + $3, 0), updated_at = now()
+		WHERE s.credits + $3 <= $4
+		RETURNING credits
+	`
+
+	var spent int64
+	err := l.DB.QueryRow(ctx, query, endpoint, day, credits, budget).Scan(&spent)
+	if err == nil {
+		return spent, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, err
+	}
+
+	// Over budget: report what has been spent so far
+	err = l.DB.QueryRow(ctx,
+		`SELECT COALESCE(MAX(credits), 0) FROM rpc_credit_spend WHERE endpoint = $1 AND day = $2::date`,
+		endpoint, day,
+	).Scan(&spent)
+	return spent, false, err
+}
@@ -0,0 +1,393 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the advisory lock held while migrating, so replicas
+// starting together apply each migration once
+const migrationLockID int64 = 0x74786e666c6f77 // "txnflow"
+
+// ErrSchemaMismatch means the database schema is not the one this build expects
+var ErrSchemaMismatch = errors.New("database schema does not match this build")
+
+// Migration is one numbered schema change, read from NNN_name.up.sql and
+// NNN_name.down.sql
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Up      string `json:"-"`
+	Down    string `json:"-"`
+
+	// Checksum is the SHA-256 of Up, recorded when the migration is applied
+	Checksum string `json:"checksum"`
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// MigrationState is a migration as the database sees it
+type MigrationState struct {
+	Migration
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+
+	// Modified means the file changed after it was applied
+	Modified bool `json:"modified"`
+
+	// Unknown means the database has it but this build doesn't, i.e. the
+	// schema is newer than the code
+	Unknown bool `json:"unknown"`
+}
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrations returns the embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return parseMigrations(sub)
+}
+
+// parseMigrations reads every migration pair in fsys. Each version needs both
+// an up and a down file.
+func parseMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %03d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			sum := sha256.Sum256(body)
+			m.Up, m.Checksum = string(body), hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up file", m)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %s has no down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies the embedded migrations and tracks them in schema_migrations
+type Migrator struct {
+	DB *pgxpool.Pool
+}
+
+func NewMigrator(pool *pgxpool.Pool) *Migrator {
+	return &Migrator{DB: pool}
+}
+
+// querier is the part of pgx shared by the pool and a single connection
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// applied reads schema_migrations, which doesn't exist before the first migration
+func applied(ctx context.Context, db querier) (map[int]appliedMigration, error) {
+	done := make(map[int]appliedMigration)
+
+	var exists bool
+	if err := db.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return done, nil
+	}
+
+	rows, err := db.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = a
+	}
+	return done, rows.Err()
+}
+
+// states merges the migrations in this build with the ones the database has applied
+func states(migrations []Migration, done map[int]appliedMigration) []MigrationState {
+	var out []MigrationState
+	known := make(map[int]bool)
+	for _, m := range migrations {
+		known[m.Version] = true
+		state := MigrationState{Migration: m}
+		if a, ok := done[m.Version]; ok {
+			state.Applied = true
+			state.AppliedAt = &a.AppliedAt
+			state.Modified = a.Checksum != m.Checksum
+		}
+		out = append(out, state)
+	}
+
+	for version, a := range done {
+		if !known[version] {
+			out = append(out, MigrationState{
+				Migration: Migration{Version: version, Name: a.Name, Checksum: a.Checksum},
+				Applied:   true,
+				AppliedAt: &a.AppliedAt,
+				Unknown:   true,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+
+	return out
+}
+
+// verify refuses to build on migrations that were edited after being applied,
+// or on a schema newer than this build
+func verify(states []MigrationState) error {
+	var problems []string
+	for _, s := range states {
+		switch {
+		case s.Modified:
+			problems = append(problems, fmt.Sprintf("%s was modified after it was applied", s.Migration))
+		case s.Unknown:
+			problems = append(problems, fmt.Sprintf("%s is applied but not in this build", s.Migration))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaMismatch, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// withLock runs fn on one connection holding the migration lock, with
+// schema_migrations in place
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer func() {
+		// A session lock outlives Release, so drop the connection if it can't be unlocked
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn.Conn())
+}
+
+// run executes one migration file and its bookkeeping in a single transaction
+func run(ctx context.Context, conn *pgx.Conn, sql, record string, args ...interface{}) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// No arguments, so pgx uses the simple protocol and files may hold several statements
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Up applies every pending migration in order and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	err = m.withLock(ctx, func(conn *pgx.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(states(migrations, done)); err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			err := run(ctx, conn, mig.Up,
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				mig.Version, mig.Name, mig.Checksum)
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %w", mig, err)
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+
+	return ran, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// the ones it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = m.withLock(ctx, func(conn *pgx.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		all := states(migrations, done)
+		if err := verify(all); err != nil {
+			return err
+		}
+
+		for i := len(all) - 1; i >= 0 && len(reverted) < steps; i-- {
+			if !all[i].Applied {
+				continue
+			}
+			mig := all[i].Migration
+			err := run(ctx, conn, mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+			if err != nil {
+				return fmt.Errorf("reverting migration %s failed: %w", mig, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists every migration known to this build or to the database
+func (m *Migrator) Status(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := applied(ctx, m.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return states(migrations, done), nil
+}
+
+// Check returns ErrSchemaMismatch unless the database is at exactly this
+// build's schema
+func (m *Migrator) Check(ctx context.Context) error {
+	all, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err := verify(all); err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range all {
+		if !s.Applied {
+			pending = append(pending, s.Migration.String())
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrSchemaMismatch, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// EnsureSchema is run by every service at startup: it applies pending
+// migrations when autoMigrate is set, and otherwise only checks the schema
+func EnsureSchema(ctx context.Context, pool *pgxpool.Pool, autoMigrate bool) ([]Migration, error) {
+	migrator := NewMigrator(pool)
+	if autoMigrate {
+		return migrator.Up(ctx)
+	}
+	return nil, migrator.Check(ctx)
+}
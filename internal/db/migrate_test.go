@@ -0,0 +1,104 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %s out of sequence, want version %d", m, i+1)
+		}
+		if len(m.Checksum) != 64 {
+			t.Errorf("migration %s checksum %q", m, m.Checksum)
+		}
+	}
+	if got := migrations[0].String(); got != "001_init" {
+		t.Errorf("first migration = %s, want 001_init", got)
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_b.up.sql":   {Data: []byte("SELECT 2;")},
+		"002_b.down.sql": {Data: []byte("SELECT -2;")},
+		"001_a.up.sql":   {Data: []byte("SELECT 1;")},
+		"001_a.down.sql": {Data: []byte("SELECT -1;")},
+		"README.md":      {Data: []byte("ignored")},
+	}
+
+	migrations, err := parseMigrations(fsys)
+	if err != nil {
+		t.Fatalf("parseMigrations: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].String() != "001_a" || migrations[1].String() != "002_b" {
+		t.Fatalf("parseMigrations = %v", migrations)
+	}
+	if migrations[1].Up != "SELECT 2;" || migrations[1].Down != "SELECT -2;" {
+		t.Errorf("002_b = %+v", migrations[1])
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"no down": {"001_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"no up":   {"001_a.down.sql": {Data: []byte("SELECT 1;")}},
+		"renamed": {
+			"001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	} {
+		if _, err := parseMigrations(fsys); err == nil {
+			t.Errorf("%s: parseMigrations succeeded", name)
+		}
+	}
+}
+
+func TestMigrationStates(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "a", Checksum: "aaa"},
+		{Version: 2, Name: "b", Checksum: "bbb"},
+		{Version: 3, Name: "c", Checksum: "ccc"},
+	}
+	now := time.Now()
+
+	all := states(migrations, map[int]appliedMigration{
+		1: {Name: "a", Checksum: "aaa", AppliedAt: now},
+		2: {Name: "b", Checksum: "bbb", AppliedAt: now},
+	})
+	if len(all) != 3 || !all[0].Applied || !all[1].Applied || all[2].Applied {
+		t.Fatalf("states = %+v", all)
+	}
+	if err := verify(all); err != nil {
+		t.Errorf("verify with a pending migration: %v", err)
+	}
+
+	edited := states(migrations, map[int]appliedMigration{
+		1: {Name: "a", Checksum: "changed", AppliedAt: now},
+	})
+	if !edited[0].Modified {
+		t.Error("edited migration not marked modified")
+	}
+	if err := verify(edited); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("verify(edited) = %v, want ErrSchemaMismatch", err)
+	}
+
+	newer := states(migrations, map[int]appliedMigration{
+		4: {Name: "d", Checksum: "ddd", AppliedAt: now},
+	})
+	if len(newer) != 4 || !newer[3].Unknown || newer[3].String() != "004_d" {
+		t.Fatalf("states with an unknown migration = %+v", newer)
+	}
+	if err := verify(newer); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("verify(newer) = %v, want ErrSchemaMismatch", err)
+	}
+}
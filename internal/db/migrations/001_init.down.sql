@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ingestion_events;
+DROP TABLE IF EXISTS transactions;
+DROP TYPE IF EXISTS transaction_status;
@@ -1,14 +1,21 @@
-CREATE TYPE transaction_status AS ENUM (
-  'RECEIVED',
-  'FETCHING',
-  'PENDING',
-  'CONFIRMED',
-  'FAILED',
-  'DROPPED',
-  'ERROR'
-);
+-- Safe to re-run against a database set up before migrations were tracked
+DO $$
+BEGIN
+  CREATE TYPE transaction_status AS ENUM (
+    'RECEIVED',
+    'FETCHING',
+    'PENDING',
+    'CONFIRMED',
+    'FAILED',
+    'DROPPED',
+    'ERROR'
+  );
+EXCEPTION
+  WHEN duplicate_object THEN NULL;
+END
+$$;
 
-CREATE TABLE transactions (
+CREATE TABLE IF NOT EXISTS transactions (
   id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
   transaction_hash TEXT NOT NULL,
   chain_id INTEGER NOT NULL,
@@ -24,7 +31,7 @@ CREATE TABLE transactions (
   UNIQUE (transaction_hash, chain_id)
 );
 
-CREATE TABLE ingestion_events (
+CREATE TABLE IF NOT EXISTS ingestion_events (
   id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
   transaction_id UUID NOT NULL REFERENCES transactions(id),
   previous_status transaction_status,
@@ -32,4 +39,3 @@ CREATE TABLE ingestion_events (
   reason TEXT,
   created_at TIMESTAMP NOT NULL DEFAULT now()
 );
-    
\ No newline at end of file
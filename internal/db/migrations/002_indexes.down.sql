@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_hash;
+DROP INDEX IF EXISTS idx_transactions_from_block;
+DROP INDEX IF EXISTS idx_transactions_to_block;
+DROP INDEX IF EXISTS idx_transactions_block_number;
+DROP INDEX IF EXISTS idx_transactions_chain_created;
+DROP INDEX IF EXISTS idx_transactions_status_created;
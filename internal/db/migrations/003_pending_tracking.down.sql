@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_pending_next_check;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS pending_since;
+ALTER TABLE transactions DROP COLUMN IF EXISTS next_check_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS check_count;
@@ -0,0 +1,12 @@
+-- Re-check schedule for PENDING transactions
+-- pending_since: when the transaction first entered PENDING (drives the drop deadline)
+-- next_check_at: earliest time the worker should poll the node again
+-- check_count:   number of re-checks so far (drives the backoff)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS pending_since TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS next_check_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS check_count INTEGER NOT NULL DEFAULT 0;
+
+-- Supports the worker's "PENDING rows due for a re-check" sweep
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_next_check
+ON transactions (next_check_at)
+WHERE status = 'PENDING';
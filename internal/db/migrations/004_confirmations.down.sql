@@ -0,0 +1,5 @@
+-- Postgres cannot drop an enum value, so INCLUDED stays in transaction_status.
+-- Rows in it go back to PENDING for the worker to re-check.
+UPDATE transactions SET status = 'PENDING', updated_at = now() WHERE status = 'INCLUDED';
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS confirmations;
@@ -0,0 +1,5 @@
+-- INCLUDED: mined successfully but not yet final by the chain's rules
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'INCLUDED' AFTER 'PENDING';
+
+-- Blocks built on top of the transaction's block (head - block_number)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS confirmations BIGINT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_chain_block;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS block_hash;
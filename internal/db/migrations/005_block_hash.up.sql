@@ -0,0 +1,7 @@
+-- Hash of the block the transaction was mined in, used to detect reorgs
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS block_hash TEXT;
+
+-- Supports the reorg watcher's "recent blocks on this chain" scan
+CREATE INDEX IF NOT EXISTS idx_transactions_chain_block
+ON transactions (chain_id, block_number)
+WHERE block_hash IS NOT NULL;
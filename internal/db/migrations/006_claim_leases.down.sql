@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS claimed_by;
+ALTER TABLE transactions DROP COLUMN IF EXISTS lease_expires_at;
@@ -0,0 +1,5 @@
+-- Worker leases so several replicas can claim rows without overlapping
+-- claimed_by:       ID of the worker currently processing the row
+-- lease_expires_at: after this the claim is considered abandoned
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS claimed_by TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Supports the reaper's "leases that have expired" scan
+CREATE INDEX IF NOT EXISTS idx_transactions_lease_expires
+ON transactions (lease_expires_at)
+WHERE lease_expires_at IS NOT NULL;
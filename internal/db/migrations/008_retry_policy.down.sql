@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_received_due;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS attempt_count;
+ALTER TABLE transactions DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS last_error;
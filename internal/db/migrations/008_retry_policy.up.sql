@@ -0,0 +1,12 @@
+-- Retry bookkeeping for failed fetches
+-- attempt_count:   claims so far, incremented each time a worker picks the row up
+-- next_attempt_at: RECEIVED rows are not claimed again before this time
+-- last_error:      most recent failure, kept even after a later success
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS attempt_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+-- Supports the claim query's "RECEIVED and due" filter
+CREATE INDEX IF NOT EXISTS idx_transactions_received_due
+ON transactions (created_at)
+WHERE status = 'RECEIVED';
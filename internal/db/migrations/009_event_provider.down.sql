@@ -0,0 +1 @@
+ALTER TABLE ingestion_events DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,2 @@
+-- RPC provider that served the data behind a status change
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS provider TEXT;
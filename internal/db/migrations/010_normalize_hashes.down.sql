@@ -0,0 +1 @@
+-- Lowercased hashes can't be restored to their original case; nothing to undo
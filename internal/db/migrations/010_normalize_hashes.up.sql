@@ -0,0 +1,16 @@
+-- The API now lowercases EVM hashes; bring existing rows in line so case
+-- variants of the same hash dedupe. Where several variants already exist,
+-- only the oldest is renamed and the rest are left for manual cleanup.
+UPDATE transactions AS t
+SET transaction_hash = lower(t.transaction_hash), updated_at = now()
+FROM (
+    SELECT DISTINCT ON (chain_id, lower(transaction_hash)) id
+    FROM transactions
+    WHERE transaction_hash <> lower(transaction_hash)
+    ORDER BY chain_id, lower(transaction_hash), created_at
+) AS oldest
+WHERE t.id = oldest.id
+  AND NOT EXISTS (
+      SELECT 1 FROM transactions o
+      WHERE o.chain_id = t.chain_id AND o.transaction_hash = lower(t.transaction_hash)
+  );
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_transactions_source_service;
+DROP INDEX IF EXISTS idx_transactions_external_reference;
+DROP INDEX IF EXISTS idx_transactions_metadata;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS source_service;
+ALTER TABLE transactions DROP COLUMN IF EXISTS metadata;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_reference;
@@ -0,0 +1,18 @@
+-- Client-supplied context for a transaction
+-- source_service:     which service submitted it
+-- metadata:           free-form JSON object, filterable with @>
+-- external_reference: caller's own ID for the transaction, e.g. an order ID
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS source_service TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_reference TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_source_service
+ON transactions (source_service, created_at DESC)
+WHERE source_service IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_external_reference
+ON transactions (external_reference)
+WHERE external_reference IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_metadata
+ON transactions USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,52 @@
+-- Webhook subscriptions: empty filter arrays / NULL filters match everything
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  url TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  chain_ids INTEGER[] NOT NULL DEFAULT '{}',
+  statuses TEXT[] NOT NULL DEFAULT '{}',
+  address TEXT,
+  source_service TEXT,
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- One row per (subscription, status change); the payload is frozen at enqueue time
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id),
+  transaction_id UUID NOT NULL REFERENCES transactions(id),
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED')),
+  attempt_count INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+  claimed_until TIMESTAMP,
+  last_error TEXT,
+  delivered_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+ON webhook_deliveries (next_attempt_at)
+WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription
+ON webhook_deliveries (subscription_id, created_at DESC);
+
+-- Every HTTP attempt, successful or not
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+  id BIGSERIAL PRIMARY KEY,
+  delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id),
+  attempt INTEGER NOT NULL,
+  response_status INTEGER,
+  response_body TEXT,
+  error TEXT,
+  duration_ms BIGINT NOT NULL,
+  attempted_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery
+ON webhook_delivery_attempts (delivery_id, attempt);
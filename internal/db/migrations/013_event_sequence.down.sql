@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_ingestion_events_seq;
+
+ALTER TABLE ingestion_events DROP COLUMN IF EXISTS seq;
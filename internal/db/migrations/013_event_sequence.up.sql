@@ -0,0 +1,6 @@
+-- Monotonic position of each event, used as the SSE event ID for resume.
+-- Existing rows are numbered when the column is added.
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS seq BIGSERIAL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingestion_events_seq
+ON ingestion_events (seq);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_ingestion_events_transaction_seq;
@@ -0,0 +1,3 @@
+-- Supports reading one transaction's audit trail in order
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_transaction_seq
+ON ingestion_events (transaction_id, seq);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_created_id;
+DROP INDEX IF EXISTS idx_transactions_status_created_id;
+DROP INDEX IF EXISTS idx_transactions_chain_created_id;
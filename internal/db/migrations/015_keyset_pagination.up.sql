@@ -0,0 +1,10 @@
+-- Keyset pagination orders by (created_at DESC, id DESC); id breaks ties so
+-- the cursor is a strict position
+CREATE INDEX IF NOT EXISTS idx_transactions_created_id
+ON transactions (created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_status_created_id
+ON transactions (status, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_chain_created_id
+ON transactions (chain_id, created_at DESC, id DESC);
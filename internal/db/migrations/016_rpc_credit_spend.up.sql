@@ -0,0 +1,9 @@
+-- Daily RPC credit spend per provider endpoint, shared by every worker replica
+-- so a provider's budget holds across replicas and restarts
+CREATE TABLE IF NOT EXISTS rpc_credit_spend (
+  endpoint TEXT NOT NULL,
+  day DATE NOT NULL,
+  credits BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (endpoint, day)
+);
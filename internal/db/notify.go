@@ -0,0 +1,5 @@
+package db
+
+// TransactionsChannel is the LISTEN/NOTIFY channel the API signals on when a
+// new transaction is registered. The payload is the transaction ID.
+const TransactionsChannel = "txnflow_transactions"
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// DefaultMaxBatchItems caps POST /transactions/batch unless configured otherwise
+const DefaultMaxBatchItems = 1000
+
+// maxBatchItemBytes bounds one encoded batch item: the metadata cap plus room
+// for the hash, chain ID, source service and external reference
+const maxBatchItemBytes = maxMetadataBytes + 4<<10
+
+type createBatchRequest struct {
+	Transactions []createTransactionRequest `json:"transactions"`
+}
+
+// batchItemResult reports what happened to one submitted item, in request order
+type batchItemResult struct {
+	Index           int           `json:"index"`
+	Result          string        `json:"result"` // created, exists or invalid
+	ID              string        `json:"id,omitempty"`
+	TransactionHash string        `json:"transaction_hash"`
+	ChainID         int           `json:"chain_id"`
+	Status          models.Status `json:"status,omitempty"`
+	CreatedAt       time.Time     `json:"created_at,omitzero"`
+	Error           *apiError     `json:"error,omitempty"`
+}
+
+// CreateTransactionsBatch registers many transactions with one idempotent
+// multi-row insert. Invalid items are reported without failing the batch.
+func (h *Handlers) CreateTransactionsBatch(w http.ResponseWriter, r *http.Request) {
+	var req createBatchRequest
+
+	//refuse to buffer more than a full batch of maximal items
+	maxBytes := int64(h.MaxBatchItems) * maxBatchItemBytes
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "batch_too_large",
+				fmt.Sprintf("a batch body may be at most %d bytes", maxBytes),
+				map[string]interface{}{"max_items": h.MaxBatchItems, "max_bytes": maxBytes})
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Transactions) == 0 {
+		http.Error(w, "no transactions in batch", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Transactions) > h.MaxBatchItems {
+		writeError(w, http.StatusRequestEntityTooLarge, "batch_too_large",
+			fmt.Sprintf("a batch may contain at most %d transactions", h.MaxBatchItems),
+			map[string]interface{}{"max_items": h.MaxBatchItems, "items": len(req.Transactions)})
+		return
+	}
+
+	results := make([]batchItemResult, len(req.Transactions))
+
+	//validate; the store resolves a repeated item to the same row as its first occurrence
+	var (
+		items   []store.NewTransaction
+		indexes []int
+	)
+	for i := range req.Transactions {
+		item := &req.Transactions[i]
+		results[i] = batchItemResult{Index: i, TransactionHash: item.TransactionHash, ChainID: item.ChainID}
+
+		if item.TransactionHash == "" || item.ChainID == 0 {
+			results[i].Result = "invalid"
+			results[i].Error = &apiError{Code: "missing_fields", Message: "transaction_hash and chain_id are required"}
+			continue
+		}
+
+		if apiErr := h.validateCreateRequest(item); apiErr != nil {
+			results[i].Result = "invalid"
+			results[i].Error = apiErr
+			continue
+		}
+		results[i].TransactionHash = item.TransactionHash
+
+		items = append(items, store.NewTransaction{
+			TransactionHash:   item.TransactionHash,
+			ChainID:           item.ChainID,
+			SourceService:     item.SourceService,
+			Metadata:          item.Metadata,
+			ExternalReference: item.ExternalReference,
+		})
+		indexes = append(indexes, i)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	created, err := h.Store.CreateBatch(ctx, items)
+	if err != nil {
+		http.Error(w, "failed to insert transactions", http.StatusInternalServerError)
+		return
+	}
+
+	for j, res := range created {
+		i := indexes[j]
+		results[i].ID, results[i].Status, results[i].CreatedAt = res.Transaction.ID, res.Transaction.Status, res.Transaction.CreatedAt
+		results[i].Result = "exists"
+		if res.Created {
+			results[i].Result = "created"
+		}
+	}
+
+	summary := map[string]int{"created": 0, "exists": 0, "invalid": 0}
+	for i := range results {
+		summary[results[i].Result]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    results,
+		"count":   len(results),
+		"summary": summary,
+	})
+}
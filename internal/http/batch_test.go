@@ -0,0 +1,118 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// batchResponse is the CreateTransactionsBatch response body
+type batchResponse struct {
+	Data    []batchItemResult `json:"data"`
+	Count   int               `json:"count"`
+	Summary map[string]int    `json:"summary"`
+}
+
+func TestCreateTransactionsBatchPerItemResults(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	var existing map[string]interface{}
+	decode(t, serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(9), "chain_id": 1}), &existing)
+
+	items := []map[string]interface{}{
+		{"transaction_hash": txHash(1), "chain_id": 1},
+		{"transaction_hash": strings.ToUpper(txHash(1)), "chain_id": 1}, // the first again
+		{"transaction_hash": "0x1234", "chain_id": 1},
+		{"transaction_hash": txHash(2), "chain_id": 56},
+		{"chain_id": 1},
+		{"transaction_hash": txHash(9), "chain_id": 1},
+		{"transaction_hash": txHash(1), "chain_id": 137},
+	}
+	rec := serve(h, http.MethodPost, "/transactions/batch", map[string]interface{}{"transactions": items})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /transactions/batch = %d: %s", rec.Code, rec.Body)
+	}
+
+	var body batchResponse
+	decode(t, rec, &body)
+	if body.Count != len(items) || len(body.Data) != len(items) {
+		t.Fatalf("count %d with %d results, want %d", body.Count, len(body.Data), len(items))
+	}
+
+	want := []struct {
+		result, code string
+	}{
+		{"created", ""},
+		{"exists", ""},
+		{"invalid", "invalid_transaction_hash"},
+		{"invalid", "unsupported_chain"},
+		{"invalid", "missing_fields"},
+		{"exists", ""},
+		{"created", ""},
+	}
+	for i, w := range want {
+		got := body.Data[i]
+		if got.Index != i || got.Result != w.result {
+			t.Errorf("item %d = %s, want %s", i, got.Result, w.result)
+		}
+		var code string
+		if got.Error != nil {
+			code = got.Error.Code
+		}
+		if code != w.code {
+			t.Errorf("item %d error = %q, want %q", i, code, w.code)
+		}
+		if w.result != "invalid" && (got.ID == "" || got.Status != "RECEIVED") {
+			t.Errorf("item %d = %+v, want the row's id and status", i, got)
+		}
+	}
+
+	if body.Data[0].ID != body.Data[1].ID || body.Data[1].TransactionHash != txHash(1) {
+		t.Errorf("repeated item = %+v, want the first item's row and normalized hash", body.Data[1])
+	}
+	if body.Data[5].ID != existing["id"] {
+		t.Errorf("resubmitted item id = %s, want %s", body.Data[5].ID, existing["id"])
+	}
+	if s := body.Summary; s["created"] != 2 || s["exists"] != 2 || s["invalid"] != 3 {
+		t.Errorf("summary = %v", s)
+	}
+}
+
+func TestCreateTransactionsBatchBounds(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	h.MaxBatchItems = 2
+
+	if rec := serve(h, http.MethodPost, "/transactions/batch", `{"transactions": []}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty batch = %d, want 400", rec.Code)
+	}
+
+	tooMany := map[string]interface{}{"transactions": []map[string]interface{}{
+		{"transaction_hash": txHash(1), "chain_id": 1},
+		{"transaction_hash": txHash(2), "chain_id": 1},
+		{"transaction_hash": txHash(3), "chain_id": 1},
+	}}
+	rec := serve(h, http.MethodPost, "/transactions/batch", tooMany)
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	decode(t, rec, &body)
+	if rec.Code != http.StatusRequestEntityTooLarge || body.Error.Code != "batch_too_large" || body.Error.Details["items"] != float64(3) {
+		t.Errorf("3 items over a limit of 2 = %d %+v", rec.Code, body.Error)
+	}
+
+	// One item padded past what two maximal items could need is cut off unread
+	padding := strings.Repeat("x", 2*maxBatchItemBytes)
+	huge := fmt.Sprintf(`{"transactions": [{"transaction_hash": %q, "chain_id": 1, "source_service": %q}]}`, txHash(1), padding)
+	rec = serve(h, http.MethodPost, "/transactions/batch", huge)
+	decode(t, rec, &body)
+	if rec.Code != http.StatusRequestEntityTooLarge || body.Error.Details["max_bytes"] != float64(2*maxBatchItemBytes) {
+		t.Errorf("oversized body = %d %+v", rec.Code, body.Error)
+	}
+
+	var list listPage
+	decode(t, serve(h, http.MethodGet, "/transactions", nil), &list)
+	if list.Count != 0 {
+		t.Errorf("%d rows stored by rejected batches", list.Count)
+	}
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+// listCursor is the position after the last row of a page in
+// (created_at DESC, id DESC) order. Clients treat it as opaque.
+type listCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func encodeCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || !models.IsUUID(c.ID) {
+		return c, errInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// apiError is the structured body returned for rejected requests
+type apiError struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// writeError sends {"error": {...}} with the given status
+func writeError(w http.ResponseWriter, status int, code, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": apiError{Code: code, Message: message, Details: details},
+	})
+}
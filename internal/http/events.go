@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetTransactionEvents returns the ordered status history of a transaction,
+// addressed by its ID or its hash (optionally scoped by chain)
+func (h *Handlers) GetTransactionEvents(w http.ResponseWriter, r *http.Request) {
+	ref := chi.URLParam(r, "id")
+	if ref == "" {
+		ref = chi.URLParam(r, "hash")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var txID string
+	if models.IsUUID(ref) {
+		txn, err := h.Store.Get(ctx, ref)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		txID = txn.ID
+	} else {
+		var chainID *int
+		if v := chi.URLParam(r, "chain_id"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "invalid chain_id", http.StatusBadRequest)
+				return
+			}
+			chainID = &parsed
+		}
+
+		matches, err := h.findTransactionsByHash(ctx, ref, chainID)
+		if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		switch len(matches) {
+		case 0:
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		case 1:
+			txID = matches[0].ID
+		default:
+			writeAmbiguousHash(w, ref, matches)
+			return
+		}
+	}
+
+	events, err := h.Store.Events(ctx, txID)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"transaction_id": txID,
+		"data":           events,
+		"count":          len(events),
+	})
+}
+
+// includes reports whether the comma-separated include parameter names what
+func includes(r *http.Request, what string) bool {
+	for _, v := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(v) == what {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// parseTransactionFilters reads the query filters shared by ListTransactions
+// and the live stream. Malformed numeric values are ignored, as they always were.
+func parseTransactionFilters(query url.Values) (store.Filter, error) {
+	filters := store.Filter{
+		FromAddress:       query.Get("from_address"),
+		ToAddress:         query.Get("to_address"),
+		Status:            models.Status(query.Get("status")),
+		SourceService:     query.Get("source_service"),
+		ExternalReference: query.Get("external_reference"),
+	}
+
+	if filters.Status != "" && !filters.Status.Valid() {
+		return filters, fmt.Errorf("unknown transaction status %q", filters.Status)
+	}
+
+	if v := query.Get("chain_id"); v != "" {
+		if chainID, err := strconv.Atoi(v); err == nil {
+			filters.ChainID = &chainID
+		}
+	}
+
+	if v := query.Get("block_number_min"); v != "" {
+		if blockNum, err := strconv.ParseInt(v, 10, 64); err == nil {
+			filters.BlockNumberMin = &blockNum
+		}
+	}
+
+	if v := query.Get("block_number_max"); v != "" {
+		if blockNum, err := strconv.ParseInt(v, 10, 64); err == nil {
+			filters.BlockNumberMax = &blockNum
+		}
+	}
+
+	//metadata={"team":"payments"} matches rows whose metadata contains that object
+	if v := query.Get("metadata"); v != "" {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &obj); err != nil {
+			return filters, errors.New("metadata filter must be a JSON object")
+		}
+		filters.Metadata = json.RawMessage(v)
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type requeueRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RequeueTransaction sends an ERROR or DROPPED transaction back to RECEIVED
+// with a fresh set of attempts
+func (h *Handlers) RequeueTransaction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !models.IsUUID(id) {
+		http.Error(w, "invalid transaction id", http.StatusBadRequest)
+		return
+	}
+
+	//the body is optional
+	var req requeueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	txn, err := h.Store.Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.Store.Requeue(ctx, store.Transition{ID: id, From: txn.Status, Reason: req.Reason})
+	switch {
+	case errors.Is(err, models.ErrIllegalTransition):
+		writeError(w, http.StatusConflict, "not_requeueable",
+			fmt.Sprintf("transaction is %s; only ERROR and DROPPED transactions can be requeued", txn.Status),
+			map[string]interface{}{"status": txn.Status})
+		return
+	case errors.Is(err, store.ErrStatusConflict):
+		writeError(w, http.StatusConflict, "status_conflict", err.Error(), nil)
+		return
+	case err != nil:
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	txn, err = h.Store.Get(ctx, id)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(txn)
+}
@@ -8,9 +8,23 @@ func Router(h *Handlers) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Post("/transactions", h.CreateTransaction)
+	r.Post("/transactions/batch", h.CreateTransactionsBatch)
 	r.Get("/transactions", h.ListTransactions)
+	r.Get("/transactions/stream", h.StreamTransactions)
 	r.Get("/transactions/{hash}", h.GetTransaction)
+	r.Get("/transactions/{id}/events", h.GetTransactionEvents)
+	r.Post("/transactions/{id}/requeue", h.RequeueTransaction)
+	r.Get("/chains/{chain_id}/transactions/{hash}", h.GetChainTransaction)
+	r.Get("/chains/{chain_id}/transactions/{hash}/events", h.GetTransactionEvents)
 	r.Get("/stats", h.GetStats)
 
+	r.Post("/webhooks", h.CreateWebhook)
+	r.Get("/webhooks", h.ListWebhooks)
+	r.Get("/webhooks/{id}", h.GetWebhook)
+	r.Delete("/webhooks/{id}", h.DeleteWebhook)
+	r.Get("/webhooks/{id}/deliveries", h.ListWebhookDeliveries)
+	r.Get("/webhooks/{id}/deliveries/{deliveryID}", h.GetWebhookDelivery)
+	r.Post("/webhooks/{id}/deliveries/{deliveryID}/redeliver", h.RedeliverWebhook)
+
 	return r
 }
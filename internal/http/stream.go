@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+const (
+	streamPollInterval = 1 * time.Second
+	streamHeartbeat    = 15 * time.Second
+	streamBatchSize    = 500
+
+	// streamGapTimeout is how long a missing seq is waited for before it is
+	// assumed to belong to a rolled-back transaction and skipped
+	streamGapTimeout = 5 * time.Second
+)
+
+// eventCursor tracks how far a stream has read through ingestion_events.
+// Sequence values are handed out before commit, so a later seq can become
+// visible before an earlier one; the cursor only advances over contiguous
+// seqs, giving each gap streamGapTimeout to fill in.
+type eventCursor struct {
+	after    int64
+	gapAt    int64
+	gapSince time.Time
+}
+
+// StreamTransactions serves status transitions as Server-Sent Events. It takes
+// the ListTransactions filters and resumes after Last-Event-ID (or the
+// last_event_id query parameter) when given, otherwise starts from now.
+func (h *Handlers) StreamTransactions(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	filters, err := parseTransactionFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	cursor := &eventCursor{}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	if lastEventID != "" {
+		cursor.after, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || cursor.after < 0 {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	} else {
+		cursor.after, err = h.Store.LatestEventSeq(ctx)
+		if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	//tell EventSource how long to wait before reconnecting
+	fmt.Fprintf(w, "retry: %d\n\n", (2 * time.Second).Milliseconds())
+	flusher.Flush()
+
+	poll := time.NewTicker(streamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+
+		case <-poll.C:
+			events, err := h.nextStreamEvents(ctx, cursor, filters)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(w, "event: error\ndata: %q\n\n", "failed to read events")
+					flusher.Flush()
+				}
+				return
+			}
+
+			for _, event := range events {
+				data, _ := json.Marshal(event)
+				fmt.Fprintf(w, "id: %d\nevent: status_changed\ndata: %s\n\n", event.Seq, data)
+			}
+			if len(events) > 0 {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// nextStreamEvents advances the cursor as far as is safe and returns the
+// matching events it passed over
+func (h *Handlers) nextStreamEvents(ctx context.Context, cursor *eventCursor, filters store.Filter) ([]models.Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	seqs, err := h.Store.EventSeqs(ctx, cursor.after, streamBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from, upTo := cursor.after, cursor.after
+	for _, seq := range seqs {
+		if seq != upTo+1 {
+			if cursor.gapAt != upTo+1 {
+				cursor.gapAt, cursor.gapSince = upTo+1, now
+			}
+			if now.Sub(cursor.gapSince) < streamGapTimeout {
+				break
+			}
+		}
+		upTo = seq
+	}
+
+	if upTo == from {
+		return nil, nil
+	}
+
+	events, err := h.Store.ListEvents(ctx, store.EventQuery{AfterSeq: from, UpToSeq: upTo, Filter: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor.after = upTo
+	return events, nil
+}
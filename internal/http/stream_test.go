@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+func TestStreamReplaysAfterLastEventID(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	for i := range 3 {
+		chainID := 1
+		if i == 1 {
+			chainID = 137
+		}
+		serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(i), "chain_id": chainID})
+	}
+
+	server := httptest.NewServer(Router(h))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/transactions/stream?chain_id=1", nil)
+	req.Header.Set("Last-Event-ID", "0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /transactions/stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("stream = %d %s", resp.StatusCode, ct)
+	}
+
+	// Events 1 and 3 are on chain 1; 2 is filtered out
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < 2 && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if !slices.Equal(ids, []string{"1", "3"}) {
+		t.Errorf("replayed ids = %v, want [1 3]", ids)
+	}
+}
+
+func TestStreamRejectsBadLastEventID(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	for _, id := range []string{"abc", "-1"} {
+		if rec := serve(h, http.MethodGet, "/transactions/stream?last_event_id="+id, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("last_event_id=%s: %d, want 400", id, rec.Code)
+		}
+	}
+}
+
+// uncommittedStore hides events whose seq has been handed out but whose
+// transaction hasn't committed yet
+type uncommittedStore struct {
+	store.TransactionStore
+
+	mu     sync.Mutex
+	hidden map[int64]bool
+}
+
+func (s *uncommittedStore) commit(seq int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.hidden, seq)
+}
+
+func (s *uncommittedStore) EventSeqs(ctx context.Context, after int64, limit int) ([]int64, error) {
+	seqs, err := s.TransactionStore.EventSeqs(ctx, after, limit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.DeleteFunc(seqs, func(seq int64) bool { return s.hidden[seq] }), err
+}
+
+func (s *uncommittedStore) ListEvents(ctx context.Context, q store.EventQuery) ([]models.Event, error) {
+	events, err := s.TransactionStore.ListEvents(ctx, q)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.DeleteFunc(events, func(e models.Event) bool { return s.hidden[e.Seq] }), err
+}
+
+// seqs returns the seq of every event
+func seqs(events []models.Event) []int64 {
+	out := make([]int64, len(events))
+	for i, e := range events {
+		out[i] = e.Seq
+	}
+	return out
+}
+
+func TestStreamWaitsForGapsToFill(t *testing.T) {
+	ctx := context.Background()
+	h, memory := newTestHandlers(t)
+	gappy := &uncommittedStore{TransactionStore: memory, hidden: map[int64]bool{2: true}}
+	h.Store = gappy
+	for i := range 3 {
+		serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(i), "chain_id": 1})
+	}
+
+	cursor := &eventCursor{}
+	events, err := h.nextStreamEvents(ctx, cursor, store.Filter{})
+	if err != nil {
+		t.Fatalf("nextStreamEvents: %v", err)
+	}
+	if got := seqs(events); !slices.Equal(got, []int64{1}) || cursor.after != 1 {
+		t.Fatalf("first read = %v (cursor %d), want to stop before the gap at 2", got, cursor.after)
+	}
+
+	// Still missing, still waiting
+	events, _ = h.nextStreamEvents(ctx, cursor, store.Filter{})
+	if len(events) != 0 || cursor.after != 1 {
+		t.Fatalf("read with the gap open = %v (cursor %d), want nothing", seqs(events), cursor.after)
+	}
+
+	// Once the earlier transaction commits, both come through in order
+	gappy.commit(2)
+	events, _ = h.nextStreamEvents(ctx, cursor, store.Filter{})
+	if got := seqs(events); !slices.Equal(got, []int64{2, 3}) || cursor.after != 3 {
+		t.Errorf("read after the gap filled = %v (cursor %d), want [2 3]", got, cursor.after)
+	}
+}
+
+func TestStreamSkipsGapsAfterTimeout(t *testing.T) {
+	ctx := context.Background()
+	h, memory := newTestHandlers(t)
+	h.Store = &uncommittedStore{TransactionStore: memory, hidden: map[int64]bool{2: true}}
+	for i := range 3 {
+		serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(i), "chain_id": 1})
+	}
+
+	cursor := &eventCursor{}
+	if _, err := h.nextStreamEvents(ctx, cursor, store.Filter{}); err != nil {
+		t.Fatalf("nextStreamEvents: %v", err)
+	}
+
+	// A seq that never shows up belonged to a rolled-back transaction
+	cursor.gapSince = time.Now().Add(-streamGapTimeout)
+	events, err := h.nextStreamEvents(ctx, cursor, store.Filter{})
+	if err != nil {
+		t.Fatalf("nextStreamEvents: %v", err)
+	}
+	if got := seqs(events); !slices.Equal(got, []int64{3}) || cursor.after != 3 {
+		t.Errorf("read after the gap timed out = %v (cursor %d), want [3]", got, cursor.after)
+	}
+}
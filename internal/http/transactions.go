@@ -10,23 +10,81 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
 	"github.com/go-chi/chi/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handlers struct {
-	DB *pgxpool.Pool
+	// DB serves the webhook endpoints; transactions go through Store
+	DB            *pgxpool.Pool
+	Store         store.TransactionStore
+	ChainRegistry *blockchain.ChainRegistry
+
+	// MaxBatchItems caps how many transactions one batch request may submit
+	MaxBatchItems int
 }
 
-func NewHandlers(db *pgxpool.Pool) *Handlers {
-	return &Handlers{DB: db}
+func NewHandlers(db *pgxpool.Pool, txStore store.TransactionStore, chainRegistry *blockchain.ChainRegistry) *Handlers {
+	return &Handlers{DB: db, Store: txStore, ChainRegistry: chainRegistry, MaxBatchItems: DefaultMaxBatchItems}
 }
 
 type createTransactionRequest struct {
-	TransactionHash string `json:"transaction_hash"`
-	ChainID         int    `json:"chain_id"`
-	SourceService   string `json:"source_service"`
+	TransactionHash   string          `json:"transaction_hash"`
+	ChainID           int             `json:"chain_id"`
+	SourceService     string          `json:"source_service"`
+	Metadata          json.RawMessage `json:"metadata"`
+	ExternalReference string          `json:"external_reference"`
+}
+
+// maxMetadataBytes bounds the metadata object a client may attach
+const maxMetadataBytes = 16 << 10
+
+// validateMetadata checks that metadata is absent, null or a JSON object of bounded size
+func validateMetadata(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return json.RawMessage("{}"), nil
+	}
+	if len(raw) > maxMetadataBytes {
+		return nil, fmt.Errorf("metadata exceeds %d bytes", maxMetadataBytes)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+	return raw, nil
+}
+
+// validateCreateRequest normalizes a submission's hash and metadata in place,
+// or explains why it can't be accepted
+func (h *Handlers) validateCreateRequest(req *createTransactionRequest) *apiError {
+	hash, err := h.ChainRegistry.NormalizeHash(req.ChainID, req.TransactionHash)
+	if errors.Is(err, blockchain.ErrUnsupportedChain) {
+		return &apiError{
+			Code:    "unsupported_chain",
+			Message: fmt.Sprintf("chain %d is not supported", req.ChainID),
+			Details: map[string]interface{}{"chain_id": req.ChainID, "supported_chains": h.ChainRegistry.GetSupportedChains()},
+		}
+	}
+	if err != nil {
+		return &apiError{
+			Code:    "invalid_transaction_hash",
+			Message: err.Error(),
+			Details: map[string]interface{}{"transaction_hash": req.TransactionHash, "chain_id": req.ChainID},
+		}
+	}
+	req.TransactionHash = hash
+
+	metadata, err := validateMetadata(req.Metadata)
+	if err != nil {
+		return &apiError{Code: "invalid_metadata", Message: err.Error()}
+	}
+	req.Metadata = metadata
+
+	return nil
 }
 
 func (h *Handlers) CreateTransaction(w http.ResponseWriter, r *http.Request) {
@@ -42,70 +100,42 @@ func (h *Handlers) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//reject what the worker could never process
+	if apiErr := h.validateCreateRequest(&req); apiErr != nil {
+		writeError(w, http.StatusUnprocessableEntity, apiErr.Code, apiErr.Message, apiErr.Details)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	//idempotent insert
-	insertQuery := `
-		INSERT INTO transactions (transaction_hash, chain_id, status, created_at, updated_at)
-		VALUES ($1, $2, 'RECEIVED', now(), now())
-		ON CONFLICT (transaction_hash, chain_id)
-		DO NOTHING
-		RETURNING id, status, created_at, updated_at
-	`
-
-	var (
-		id        string
-		status    string
-		createdAt time.Time
-		updatedAt time.Time
-	)
-
-	err := h.DB.QueryRow(
-		ctx,
-		insertQuery,
-		req.TransactionHash,
-		req.ChainID,
-	).Scan(&id, &status, &createdAt, &updatedAt)
-
-	//fetch existing row if insert didn't succeed
+	txn, _, err := h.Store.Create(ctx, store.NewTransaction{
+		TransactionHash:   req.TransactionHash,
+		ChainID:           req.ChainID,
+		SourceService:     req.SourceService,
+		Metadata:          req.Metadata,
+		ExternalReference: req.ExternalReference,
+	})
 	if err != nil {
-		selectQuery := `
-			SELECT id, status, created_at, updated_at
-			FROM transactions
-			WHERE transaction_hash = $1 AND chain_id = $2
-		`
-
-		row := h.DB.QueryRow(
-			ctx,
-			selectQuery,
-			req.TransactionHash,
-			req.ChainID,
-		)
-
-		if err := row.Scan(&id, &status, &createdAt, &updatedAt); err != nil {
-			http.Error(w, "failed to fetch transaction", http.StatusInternalServerError)
-			return
-		}
+		http.Error(w, "failed to fetch transaction", http.StatusInternalServerError)
+		return
 	}
 
-	//store ingestion event
-	_, _ = h.DB.Exec(
-		ctx,
-		`INSERT INTO ingestion_events (transaction_id, new_status, reason)
-		 VALUES ($1, $2, $3)`,
-		id,
-		status,
-		"transaction registered",
-	)
-
 	//build response
 	resp := map[string]interface{}{
-		"id":               id,
-		"transaction_hash": req.TransactionHash,
-		"chain_id":         req.ChainID,
-		"status":           status,
-		"created_at":       createdAt,
+		"id":               txn.ID,
+		"transaction_hash": txn.TransactionHash,
+		"chain_id":         txn.ChainID,
+		"status":           txn.Status,
+		"metadata":         txn.Metadata,
+		"created_at":       txn.CreatedAt,
+	}
+	if txn.SourceService != nil {
+		resp["source_service"] = *txn.SourceService
+	}
+	if txn.ExternalReference != nil {
+		resp["external_reference"] = *txn.ExternalReference
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -113,19 +143,12 @@ func (h *Handlers) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// Transaction is a stored transaction as served by the API
 type Transaction struct {
-	ID              string    `json:"id"`
-	TransactionHash string    `json:"transaction_hash"`
-	ChainID         int       `json:"chain_id"`
-	Status          string    `json:"status"`
-	FromAddress     *string   `json:"from_address,omitempty"`
-	ToAddress       *string   `json:"to_address,omitempty"`
-	Value           *string   `json:"value,omitempty"`
-	BlockNumber     *int64    `json:"block_number,omitempty"`
-	GasUsed         *int64    `json:"gas_used,omitempty"`
-	ErrorReason     *string   `json:"error_reason,omitempty"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	models.Transaction
+
+	// Events is only populated for GetTransaction with include=events
+	Events []models.Event `json:"events,omitempty"`
 }
 
 func (h *Handlers) GetTransaction(w http.ResponseWriter, r *http.Request) {
@@ -135,209 +158,164 @@ func (h *Handlers) GetTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.serveTransaction(w, r, hash, nil)
+}
+
+// GetChainTransaction looks a hash up on one chain, which is always unambiguous
+func (h *Handlers) GetChainTransaction(w http.ResponseWriter, r *http.Request) {
+	chainID, err := strconv.Atoi(chi.URLParam(r, "chain_id"))
+	if err != nil {
+		http.Error(w, "invalid chain_id", http.StatusBadRequest)
+		return
+	}
+
+	hash := chi.URLParam(r, "hash")
+	if hash == "" {
+		http.Error(w, "transaction hash is required", http.StatusBadRequest)
+		return
+	}
+
+	h.serveTransaction(w, r, hash, &chainID)
+}
+
+// serveTransaction writes the transaction with the given hash, or 300 with
+// every match when the hash is tracked on more than one chain. Without a
+// chain, a transaction ID is accepted in place of the hash.
+func (h *Handlers) serveTransaction(w http.ResponseWriter, r *http.Request, hash string, chainID *int) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	query := `
-		SELECT 
-			id, 
-			transaction_hash, 
-			chain_id, 
-			status, 
-			from_address, 
-			to_address, 
-			value, 
-			block_number, 
-			gas_used, 
-			error_reason, 
-			created_at, 
-			updated_at
-		FROM transactions
-		WHERE transaction_hash = $1
-		LIMIT 1
-	`
-
-	var txn Transaction
-	err := h.DB.QueryRow(ctx, query, hash).Scan(
-		&txn.ID,
-		&txn.TransactionHash,
-		&txn.ChainID,
-		&txn.Status,
-		&txn.FromAddress,
-		&txn.ToAddress,
-		&txn.Value,
-		&txn.BlockNumber,
-		&txn.GasUsed,
-		&txn.ErrorReason,
-		&txn.CreatedAt,
-		&txn.UpdatedAt,
-	)
-
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			http.Error(w, "transaction not found", http.StatusNotFound)
-			return
+	var matches []models.Transaction
+	var err error
+	if chainID == nil && models.IsUUID(hash) {
+		var txn models.Transaction
+		txn, err = h.Store.Get(ctx, hash)
+		if err == nil {
+			matches = append(matches, txn)
+		} else if errors.Is(err, store.ErrNotFound) {
+			err = nil
 		}
+	} else {
+		matches, err = h.findTransactionsByHash(ctx, hash, chainID)
+	}
+	if err != nil {
 		http.Error(w, "database error", http.StatusInternalServerError)
 		return
 	}
 
+	switch len(matches) {
+	case 0:
+		http.Error(w, "transaction not found", http.StatusNotFound)
+		return
+	case 1:
+	default:
+		writeAmbiguousHash(w, hash, matches)
+		return
+	}
+
+	txn := Transaction{Transaction: matches[0]}
+	if includes(r, "events") {
+		txn.Events, err = h.Store.Events(ctx, txn.ID)
+		if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(txn)
 }
 
+// findTransactionsByHash returns every transaction with the hash, optionally
+// restricted to one chain, ordered by chain ID
+func (h *Handlers) findTransactionsByHash(ctx context.Context, hash string, chainID *int) ([]models.Transaction, error) {
+	//hashes are stored normalized to lowercase
+	return h.Store.FindByHash(ctx, strings.ToLower(hash), chainID)
+}
+
+// writeAmbiguousHash answers 300 Multiple Choices with every match and the
+// chain-scoped URL that selects each one
+func writeAmbiguousHash(w http.ResponseWriter, hash string, matches []models.Transaction) {
+	locations := make([]string, len(matches))
+	for i, txn := range matches {
+		locations[i] = fmt.Sprintf("/chains/%d/transactions/%s", txn.ChainID, txn.TransactionHash)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMultipleChoices)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":      "ambiguous_transaction_hash",
+		"message":   fmt.Sprintf("transaction hash %s is tracked on %d chains; specify the chain", hash, len(matches)),
+		"data":      matches,
+		"count":     len(matches),
+		"locations": locations,
+	})
+}
+
 func (h *Handlers) ListTransactions(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	filters := struct {
-		FromAddress    string
-		ToAddress      string
-		ChainID        string
-		Status         string
-		BlockNumberMin string
-		BlockNumberMax string
-		Limit          string
-		Offset         string
-	}{
-		FromAddress:    query.Get("from_address"),
-		ToAddress:      query.Get("to_address"),
-		ChainID:        query.Get("chain_id"),
-		Status:         query.Get("status"),
-		BlockNumberMin: query.Get("block_number_min"),
-		BlockNumberMax: query.Get("block_number_max"),
-		Limit:          query.Get("limit"),
-		Offset:         query.Get("offset"),
+	filters, err := parseTransactionFilters(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	//default pagination values
 	limit := 100
 	offset := 0
 
-	if filters.Limit != "" {
-		if parsed, err := strconv.Atoi(filters.Limit); err == nil && parsed > 0 && parsed <= 1000 {
+	if v := query.Get("limit"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 1000 {
 			limit = parsed
 		}
 	}
 
-	if filters.Offset != "" {
-		if parsed, err := strconv.Atoi(filters.Offset); err == nil && parsed >= 0 {
+	if v := query.Get("offset"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
 			offset = parsed
 		}
 	}
 
-	baseQuery := `
-		SELECT 
-			id, 
-			transaction_hash, 
-			chain_id, 
-			status, 
-			from_address, 
-			to_address, 
-			value, 
-			block_number, 
-			gas_used, 
-			error_reason, 
-			created_at, 
-			updated_at
-		FROM transactions
-		WHERE 1=1
-	`
-
-	var conditions []string
-	var args []interface{}
-	argCounter := 1
-
-	if filters.FromAddress != "" {
-		conditions = append(conditions, fmt.Sprintf("AND from_address = $%d", argCounter))
-		args = append(args, filters.FromAddress)
-		argCounter++
-	}
-
-	if filters.ToAddress != "" {
-		conditions = append(conditions, fmt.Sprintf("AND to_address = $%d", argCounter))
-		args = append(args, filters.ToAddress)
-		argCounter++
-	}
-
-	if filters.ChainID != "" {
-		if chainID, err := strconv.Atoi(filters.ChainID); err == nil {
-			conditions = append(conditions, fmt.Sprintf("AND chain_id = $%d", argCounter))
-			args = append(args, chainID)
-			argCounter++
-		}
-	}
+	//one extra row tells us whether there is another page
+	q := store.ListQuery{Filter: filters, Limit: limit + 1, Offset: offset}
 
-	if filters.Status != "" {
-		conditions = append(conditions, fmt.Sprintf("AND status = $%d", argCounter))
-		args = append(args, filters.Status)
-		argCounter++
-	}
-
-	if filters.BlockNumberMin != "" {
-		if blockNum, err := strconv.ParseInt(filters.BlockNumberMin, 10, 64); err == nil {
-			conditions = append(conditions, fmt.Sprintf("AND block_number >= $%d", argCounter))
-			args = append(args, blockNum)
-			argCounter++
-		}
-	}
-
-	if filters.BlockNumberMax != "" {
-		if blockNum, err := strconv.ParseInt(filters.BlockNumberMax, 10, 64); err == nil {
-			conditions = append(conditions, fmt.Sprintf("AND block_number <= $%d", argCounter))
-			args = append(args, blockNum)
-			argCounter++
+	//a cursor takes precedence over offset
+	if v := query.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
+		q.After = &store.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+		offset = 0
 	}
 
-	fullQuery := baseQuery + " " + strings.Join(conditions, " ") + 
-		fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argCounter, argCounter+1)
-	args = append(args, limit, offset)
-
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	rows, err := h.DB.Query(ctx, fullQuery, args...)
+	transactions, err := h.Store.List(ctx, q)
 	if err != nil {
 		http.Error(w, "database error", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	var transactions []Transaction
-	for rows.Next() {
-		var txn Transaction
-		err := rows.Scan(
-			&txn.ID,
-			&txn.TransactionHash,
-			&txn.ChainID,
-			&txn.Status,
-			&txn.FromAddress,
-			&txn.ToAddress,
-			&txn.Value,
-			&txn.BlockNumber,
-			&txn.GasUsed,
-			&txn.ErrorReason,
-			&txn.CreatedAt,
-			&txn.UpdatedAt,
-		)
-		if err != nil {
-			http.Error(w, "failed to scan result", http.StatusInternalServerError)
-			return
-		}
-		transactions = append(transactions, txn)
-	}
 
-	if err := rows.Err(); err != nil {
-		http.Error(w, "error reading results", http.StatusInternalServerError)
-		return
+	hasMore := len(transactions) > limit
+	if hasMore {
+		transactions = transactions[:limit]
 	}
 
 	response := map[string]interface{}{
-		"data":   transactions,
-		"limit":  limit,
-		"offset": offset,
-		"count":  len(transactions),
+		"data":     transactions,
+		"limit":    limit,
+		"offset":   offset,
+		"count":    len(transactions),
+		"has_more": hasMore,
+	}
+	if hasMore {
+		last := transactions[len(transactions)-1]
+		response["next_cursor"] = encodeCursor(listCursor{CreatedAt: last.CreatedAt, ID: last.ID})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -350,46 +328,25 @@ func (h *Handlers) GetStats(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Count transactions by status
-	query := `
-		SELECT status, COUNT(*) 
-		FROM transactions 
-		GROUP BY status
-	`
-
-	rows, err := h.DB.Query(ctx, query)
+	stats, err := h.Store.CountByStatus(ctx)
 	if err != nil {
 		http.Error(w, "database error", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	stats := make(map[string]int)
 	totalCount := 0
-
-	for rows.Next() {
-		var status string
-		var count int
-		if err := rows.Scan(&status, &count); err != nil {
-			http.Error(w, "failed to scan result", http.StatusInternalServerError)
-			return
-		}
-		stats[status] = count
+	for _, count := range stats {
 		totalCount += count
 	}
 
-	if err := rows.Err(); err != nil {
-		http.Error(w, "error reading results", http.StatusInternalServerError)
-		return
-	}
-
 	// Build response
 	response := map[string]interface{}{
-		"total":        totalCount,
-		"by_status":    stats,
-		"timestamp":    time.Now(),
+		"total":     totalCount,
+		"by_status": stats,
+		"timestamp": time.Now(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
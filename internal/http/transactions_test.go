@@ -0,0 +1,321 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// newTestHandlers returns handlers for chains 1 and 137 backed by a MemoryStore
+func newTestHandlers(t *testing.T) (*Handlers, *store.MemoryStore) {
+	t.Helper()
+
+	registry := blockchain.NewChainRegistry("")
+	registry.RegisterChain(&blockchain.ChainConfig{
+		ChainID:   137,
+		Name:      "Polygon",
+		Type:      blockchain.ChainTypeEVM,
+		Endpoints: []blockchain.Endpoint{{Name: "unused", URL: "http://127.0.0.1:0"}},
+	})
+
+	txStore := store.NewMemoryStore()
+	return NewHandlers(nil, txStore, registry), txStore
+}
+
+// serve runs one request through the router
+func serve(h *Handlers, method, target string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	switch b := body.(type) {
+	case nil:
+	case string:
+		payload.WriteString(b)
+	default:
+		_ = json.NewEncoder(&payload).Encode(b)
+	}
+
+	rec := httptest.NewRecorder()
+	Router(h).ServeHTTP(rec, httptest.NewRequest(method, target, &payload))
+	return rec
+}
+
+// decode unmarshals a response body into v
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+}
+
+// txHash returns a distinct, well-formed transaction hash
+func txHash(n int) string {
+	return fmt.Sprintf("0x%064x", n)
+}
+
+func TestCreateTransactionValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "not json", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "missing hash", body: `{"chain_id": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "unsupported chain", body: fmt.Sprintf(`{"transaction_hash": %q, "chain_id": 56}`, txHash(1)), wantStatus: http.StatusUnprocessableEntity, wantCode: "unsupported_chain"},
+		{name: "short hash", body: `{"transaction_hash": "0x1234", "chain_id": 1}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "invalid_transaction_hash"},
+		{name: "non-hex hash", body: `{"transaction_hash": "0x` + strings.Repeat("g", 64) + `", "chain_id": 1}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "invalid_transaction_hash"},
+		{name: "metadata array", body: fmt.Sprintf(`{"transaction_hash": %q, "chain_id": 1, "metadata": [1]}`, txHash(1)), wantStatus: http.StatusUnprocessableEntity, wantCode: "invalid_metadata"},
+		{name: "metadata too large", body: fmt.Sprintf(`{"transaction_hash": %q, "chain_id": 1, "metadata": {"k": %q}}`, txHash(1), strings.Repeat("x", maxMetadataBytes)), wantStatus: http.StatusUnprocessableEntity, wantCode: "invalid_metadata"},
+		{name: "valid", body: fmt.Sprintf(`{"transaction_hash": %q, "chain_id": 1, "metadata": {"order": 7}}`, txHash(1)), wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandlers(t)
+
+			rec := serve(h, http.MethodPost, "/transactions", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			decode(t, rec, &body)
+			if body.Error.Code != tt.wantCode || body.Error.Message == "" {
+				t.Errorf("error = %+v, want code %s", body.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCreateTransactionNormalizesHash(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	upper := "0x" + strings.Repeat("AB", 32)
+
+	var first, second map[string]interface{}
+	decode(t, serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": upper, "chain_id": 1}), &first)
+	decode(t, serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": strings.ToLower(upper), "chain_id": 1}), &second)
+
+	if first["transaction_hash"] != strings.ToLower(upper) || first["id"] != second["id"] {
+		t.Errorf("submissions differing in case: %v and %v, want one lowercased row", first, second)
+	}
+}
+
+func TestUnsupportedChainListsSupportedChains(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	rec := serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(1), "chain_id": 56})
+	var body struct {
+		Error struct {
+			Details struct {
+				SupportedChains []int `json:"supported_chains"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	decode(t, rec, &body)
+	if got := body.Error.Details.SupportedChains; len(got) != 2 || got[0] != 1 || got[1] != 137 {
+		t.Errorf("supported_chains = %v, want [1 137]", got)
+	}
+}
+
+func TestGetTransactionAmbiguousHash(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	hash := txHash(1)
+	for _, chainID := range []int{137, 1} {
+		if rec := serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": hash, "chain_id": chainID}); rec.Code != http.StatusCreated {
+			t.Fatalf("create on chain %d = %d", chainID, rec.Code)
+		}
+	}
+	single := txHash(2)
+	serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": single, "chain_id": 1})
+
+	for _, target := range []string{"/transactions/" + hash, "/transactions/" + hash + "/events"} {
+		rec := serve(h, http.MethodGet, target, nil)
+		if rec.Code != http.StatusMultipleChoices {
+			t.Fatalf("GET %s = %d, want 300: %s", target, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			Code      string               `json:"code"`
+			Count     int                  `json:"count"`
+			Data      []models.Transaction `json:"data"`
+			Locations []string             `json:"locations"`
+		}
+		decode(t, rec, &body)
+		want := []string{"/chains/1/transactions/" + hash, "/chains/137/transactions/" + hash}
+		if body.Code != "ambiguous_transaction_hash" || body.Count != 2 || len(body.Locations) != 2 ||
+			body.Locations[0] != want[0] || body.Locations[1] != want[1] {
+			t.Errorf("GET %s = %+v, want both chains ordered by chain ID", target, body)
+		}
+	}
+
+	// Each location picks one
+	var txn models.Transaction
+	rec := serve(h, http.MethodGet, "/chains/137/transactions/"+hash, nil)
+	decode(t, rec, &txn)
+	if rec.Code != http.StatusOK || txn.ChainID != 137 {
+		t.Errorf("chain-scoped GET = %d chain %d", rec.Code, txn.ChainID)
+	}
+
+	// So does the ID, and a hash tracked once needs no chain
+	rec = serve(h, http.MethodGet, "/transactions/"+txn.ID+"?include=events", nil)
+	var withEvents Transaction
+	decode(t, rec, &withEvents)
+	if rec.Code != http.StatusOK || withEvents.ChainID != 137 || len(withEvents.Events) != 1 {
+		t.Errorf("GET by ID = %d %+v, want the chain 137 row with its event", rec.Code, withEvents)
+	}
+	if rec := serve(h, http.MethodGet, "/transactions/"+single, nil); rec.Code != http.StatusOK {
+		t.Errorf("GET unambiguous hash = %d", rec.Code)
+	}
+
+	if rec := serve(h, http.MethodGet, "/transactions/"+txHash(99), nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET unknown hash = %d, want 404", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/chains/56/transactions/"+hash, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET on an untracked chain = %d, want 404", rec.Code)
+	}
+}
+
+// listPage is the ListTransactions response body
+type listPage struct {
+	Data       []models.Transaction `json:"data"`
+	Count      int                  `json:"count"`
+	HasMore    bool                 `json:"has_more"`
+	NextCursor string               `json:"next_cursor"`
+}
+
+func TestListTransactionsCursorPagination(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	var created []string
+	for i := range 5 {
+		var txn map[string]interface{}
+		decode(t, serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(i), "chain_id": 1}), &txn)
+		created = append(created, txn["id"].(string))
+	}
+
+	// Walk the pages, newest first
+	var seen []string
+	target := "/transactions?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+
+		rec := serve(h, http.MethodGet, target, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", target, rec.Code, rec.Body)
+		}
+		var page listPage
+		decode(t, rec, &page)
+		for _, txn := range page.Data {
+			seen = append(seen, txn.ID)
+		}
+
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("last page has next_cursor %q", page.NextCursor)
+			}
+			break
+		}
+		target = "/transactions?limit=2&cursor=" + page.NextCursor
+	}
+
+	if len(seen) != len(created) {
+		t.Fatalf("paged through %d rows, want %d", len(seen), len(created))
+	}
+	for i, id := range seen {
+		if want := created[len(created)-1-i]; id != want {
+			t.Errorf("row %d = %s, want %s", i, id, want)
+		}
+	}
+
+	// Rows created after the first page don't shift later pages
+	var first listPage
+	decode(t, serve(h, http.MethodGet, "/transactions?limit=2", nil), &first)
+	serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(10), "chain_id": 1})
+	var second listPage
+	decode(t, serve(h, http.MethodGet, "/transactions?limit=2&cursor="+first.NextCursor, nil), &second)
+	if len(second.Data) != 2 || second.Data[0].ID != created[2] {
+		t.Errorf("second page after an insert = %+v, want to start at %s", second.Data, created[2])
+	}
+}
+
+func TestListTransactionsRejectsTamperedCursors(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	cursors := map[string]string{
+		"not base64":    "!!!",
+		"not json":      encode("created_at=yesterday"),
+		"missing id":    encode(`{"c":"2026-01-01T00:00:00Z"}`),
+		"id not a uuid": encode(`{"c":"2026-01-01T00:00:00Z","i":"1 OR 1=1"}`),
+		"bad timestamp": encode(`{"c":"yesterday","i":"8c6f1e0a-5d1b-4c1e-9a61-0c1f3b0f6a11"}`),
+	}
+
+	for name, cursor := range cursors {
+		rec := serve(h, http.MethodGet, "/transactions?cursor="+cursor, nil)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), errInvalidCursor.Error()) {
+			t.Errorf("%s: GET = %d %q, want 400 invalid cursor", name, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestListTransactionsRejectsUnknownStatus(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	if rec := serve(h, http.MethodGet, "/transactions?status=DONE", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET ?status=DONE = %d, want 400", rec.Code)
+	}
+}
+
+func TestTransactionMetadataRoundTrip(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	rec := serve(h, http.MethodPost, "/transactions", map[string]interface{}{
+		"transaction_hash":   txHash(1),
+		"chain_id":           1,
+		"source_service":     "checkout",
+		"external_reference": "order-7",
+		"metadata":           map[string]interface{}{"team": "payments", "priority": 2},
+	})
+	var created map[string]interface{}
+	decode(t, rec, &created)
+	if created["source_service"] != "checkout" || created["external_reference"] != "order-7" {
+		t.Errorf("created = %v", created)
+	}
+	serve(h, http.MethodPost, "/transactions", map[string]interface{}{"transaction_hash": txHash(2), "chain_id": 1, "source_service": "payouts"})
+
+	var page listPage
+	decode(t, serve(h, http.MethodGet, "/transactions?metadata="+url.QueryEscape(`{"team":"payments"}`), nil), &page)
+	if page.Count != 1 || page.Data[0].ID != created["id"] {
+		t.Fatalf("metadata filter = %+v", page.Data)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(page.Data[0].Metadata, &metadata); err != nil || metadata["team"] != "payments" || metadata["priority"] != float64(2) {
+		t.Errorf("metadata = %s", page.Data[0].Metadata)
+	}
+
+	decode(t, serve(h, http.MethodGet, "/transactions?source_service=payouts", nil), &page)
+	if page.Count != 1 || string(page.Data[0].Metadata) != "{}" {
+		t.Errorf("source_service filter = %+v, want the row without metadata", page.Data)
+	}
+
+	if rec := serve(h, http.MethodGet, "/transactions?metadata=[1]", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("array metadata filter = %d, want 400", rec.Code)
+	}
+}
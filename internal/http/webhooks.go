@@ -0,0 +1,449 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type createWebhookRequest struct {
+	URL           string   `json:"url"`
+	Secret        string   `json:"secret"`
+	ChainIDs      []int    `json:"chain_ids"`
+	Statuses      []string `json:"statuses"`
+	Address       string   `json:"address"`
+	SourceService string   `json:"source_service"`
+}
+
+type WebhookSubscription struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	Secret        string    `json:"secret,omitempty"`
+	ChainIDs      []int32   `json:"chain_ids"`
+	Statuses      []string  `json:"statuses"`
+	Address       *string   `json:"address,omitempty"`
+	SourceService *string   `json:"source_service,omitempty"`
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string            `json:"id"`
+	SubscriptionID string            `json:"subscription_id"`
+	TransactionID  string            `json:"transaction_id"`
+	EventType      string            `json:"event_type"`
+	Status         string            `json:"status"`
+	AttemptCount   int               `json:"attempt_count"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"`
+	LastError      *string           `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage   `json:"payload,omitempty"`
+	Attempts       []DeliveryAttempt `json:"attempts,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type DeliveryAttempt struct {
+	Attempt        int       `json:"attempt"`
+	ResponseStatus *int      `json:"response_status,omitempty"`
+	ResponseBody   *string   `json:"response_body,omitempty"`
+	Error          *string   `json:"error,omitempty"`
+	DurationMS     int64     `json:"duration_ms"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+}
+
+const subscriptionColumns = `
+	id, url, chain_ids, statuses, address, source_service, active, created_at, updated_at
+`
+
+func scanSubscription(row pgx.Row, sub *WebhookSubscription) error {
+	return row.Scan(
+		&sub.ID,
+		&sub.URL,
+		&sub.ChainIDs,
+		&sub.Statuses,
+		&sub.Address,
+		&sub.SourceService,
+		&sub.Active,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+}
+
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_url", "url must be an absolute http(s) URL", nil)
+		return
+	}
+
+	for _, chainID := range req.ChainIDs {
+		if !h.ChainRegistry.IsSupported(chainID) {
+			writeError(w, http.StatusUnprocessableEntity, "unsupported_chain",
+				fmt.Sprintf("chain %d is not supported", chainID),
+				map[string]interface{}{"chain_id": chainID, "supported_chains": h.ChainRegistry.GetSupportedChains()})
+			return
+		}
+	}
+	if req.ChainIDs == nil {
+		req.ChainIDs = []int{}
+	}
+
+	for i, status := range req.Statuses {
+		req.Statuses[i] = strings.ToUpper(status)
+		if !models.Status(req.Statuses[i]).Valid() {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_status",
+				fmt.Sprintf("unknown transaction status %q", status), nil)
+			return
+		}
+	}
+	if req.Statuses == nil {
+		req.Statuses = []string{}
+	}
+
+	//a secret is generated when the caller doesn't bring one; it is only ever returned here
+	if req.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			http.Error(w, "failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+		req.Secret = secret
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, chain_ids, statuses, address, source_service)
+		VALUES ($1, $2, $3, $4, NULLIF(lower($5), ''), NULLIF($6, ''))
+		RETURNING ` + subscriptionColumns
+
+	var sub WebhookSubscription
+	err := scanSubscription(h.DB.QueryRow(ctx, query,
+		req.URL, req.Secret, req.ChainIDs, req.Statuses, req.Address, req.SourceService,
+	), &sub)
+	if err != nil {
+		http.Error(w, "failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	sub.Secret = req.Secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(sub)
+}
+
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE active ORDER BY created_at DESC`
+
+	rows, err := h.DB.Query(ctx, query)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	subscriptions := []WebhookSubscription{}
+	for rows.Next() {
+		var sub WebhookSubscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			http.Error(w, "failed to scan result", http.StatusInternalServerError)
+			return
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, "error reading results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  subscriptions,
+		"count": len(subscriptions),
+	})
+}
+
+func (h *Handlers) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !models.IsUUID(id) {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	var sub WebhookSubscription
+	if err := scanSubscription(h.DB.QueryRow(ctx, query, id), &sub); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "webhook not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(sub)
+}
+
+// DeleteWebhook deactivates a subscription. Delivery history is kept; deliveries
+// still waiting to be sent, including ones a dispatcher is sending right now,
+// are failed.
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !models.IsUUID(id) {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE webhook_subscriptions SET active = false, updated_at = now()
+		WHERE id = $1 AND active
+	`, id)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'FAILED', last_error = 'subscription deleted', claimed_until = NULL, updated_at = now()
+		WHERE subscription_id = $1 AND status = 'PENDING'
+	`, id)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !models.IsUUID(id) {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	limit := 100
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 && parsed <= 1000 {
+		limit = parsed
+	}
+	status := strings.ToUpper(r.URL.Query().Get("status"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, subscription_id, transaction_id, event_type, status, attempt_count,
+			CASE WHEN status = 'PENDING' THEN next_attempt_at END,
+			last_error, delivered_at, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := h.DB.Query(ctx, query, id, status, limit)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.SubscriptionID,
+			&d.TransactionID,
+			&d.EventType,
+			&d.Status,
+			&d.AttemptCount,
+			&d.NextAttemptAt,
+			&d.LastError,
+			&d.DeliveredAt,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			http.Error(w, "failed to scan result", http.StatusInternalServerError)
+			return
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, "error reading results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  deliveries,
+		"limit": limit,
+		"count": len(deliveries),
+	})
+}
+
+// GetWebhookDelivery returns one delivery with its payload and every attempt
+func (h *Handlers) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID := chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID")
+	if !models.IsUUID(id) || !models.IsUUID(deliveryID) {
+		http.Error(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, subscription_id, transaction_id, event_type, status, attempt_count,
+			CASE WHEN status = 'PENDING' THEN next_attempt_at END,
+			last_error, delivered_at, payload, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE id = $1 AND subscription_id = $2
+	`
+
+	var d WebhookDelivery
+	err := h.DB.QueryRow(ctx, query, deliveryID, id).Scan(
+		&d.ID,
+		&d.SubscriptionID,
+		&d.TransactionID,
+		&d.EventType,
+		&d.Status,
+		&d.AttemptCount,
+		&d.NextAttemptAt,
+		&d.LastError,
+		&d.DeliveredAt,
+		&d.Payload,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "delivery not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		SELECT attempt, response_status, response_body, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempted_at
+	`, deliveryID)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a DeliveryAttempt
+		if err := rows.Scan(&a.Attempt, &a.ResponseStatus, &a.ResponseBody, &a.Error, &a.DurationMS, &a.AttemptedAt); err != nil {
+			http.Error(w, "failed to scan result", http.StatusInternalServerError)
+			return
+		}
+		d.Attempts = append(d.Attempts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		http.Error(w, "error reading results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(d)
+}
+
+// RedeliverWebhook queues a delivery to be sent again right away. Attempts keep
+// counting, so one that had used up its retries gets a single further try.
+func (h *Handlers) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID := chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID")
+	if !models.IsUUID(id) || !models.IsUUID(deliveryID) {
+		http.Error(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE webhook_deliveries AS d
+		SET status = 'PENDING', next_attempt_at = now(), delivered_at = NULL, updated_at = now()
+		FROM webhook_subscriptions AS s
+		WHERE d.id = $1 AND d.subscription_id = $2 AND s.id = d.subscription_id AND s.active
+		RETURNING d.id
+	`
+
+	var redelivered string
+	if err := h.DB.QueryRow(ctx, query, deliveryID, id).Scan(&redelivered); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "delivery not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     redelivered,
+		"status": "PENDING",
+	})
+}
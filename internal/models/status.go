@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is a value of the transaction_status enum
+type Status string
+
+const (
+	StatusReceived  Status = "RECEIVED"  // Registered, waiting for a worker
+	StatusFetching  Status = "FETCHING"  // Claimed by a worker, lease held
+	StatusPending   Status = "PENDING"   // Known to the node, not mined yet
+	StatusIncluded  Status = "INCLUDED"  // Mined, not final yet
+	StatusConfirmed Status = "CONFIRMED" // Mined and final
+	StatusFailed    Status = "FAILED"    // Mined and reverted
+	StatusDropped   Status = "DROPPED"   // Vanished from the mempool
+	StatusError     Status = "ERROR"     // Dead-lettered until an operator requeues it
+)
+
+// transitions is the status state machine: each status maps to the
+// statuses it may move to
+var transitions = map[Status][]Status{
+	StatusReceived: {StatusFetching},
+	// The worker resolves a fetch, or gives the row back to retry later
+	StatusFetching: {StatusReceived, StatusPending, StatusIncluded, StatusConfirmed, StatusFailed, StatusError},
+	StatusPending:  {StatusIncluded, StatusConfirmed, StatusFailed, StatusDropped},
+	StatusIncluded: {StatusConfirmed, StatusReceived},
+	// Mined outcomes are only undone by a reorg
+	StatusConfirmed: {StatusReceived},
+	StatusFailed:    {StatusReceived},
+	// Operator requeues
+	StatusDropped: {StatusReceived},
+	StatusError:   {StatusReceived},
+}
+
+// Statuses returns every status in lifecycle order
+func Statuses() []Status {
+	return []Status{
+		StatusReceived, StatusFetching, StatusPending, StatusIncluded,
+		StatusConfirmed, StatusFailed, StatusDropped, StatusError,
+	}
+}
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether the state machine allows s → to
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Requeueable reports whether s is a parked status an operator may send back
+// to RECEIVED
+func (s Status) Requeueable() bool {
+	return s == StatusError || s == StatusDropped
+}
+
+// ErrIllegalTransition is matched by every IllegalTransitionError
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// IllegalTransitionError is returned for a status change the state machine forbids
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("illegal status transition %s → %s", e.From, e.To)
+}
+
+func (e *IllegalTransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// ValidateTransition returns an IllegalTransitionError unless from → to is allowed
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	return nil
+}
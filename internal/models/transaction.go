@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Transaction is a tracked transaction as stored and served by the API
+type Transaction struct {
+	ID                string          `json:"id"`
+	TransactionHash   string          `json:"transaction_hash"`
+	ChainID           int             `json:"chain_id"`
+	Status            Status          `json:"status"`
+	FromAddress       *string         `json:"from_address,omitempty"`
+	ToAddress         *string         `json:"to_address,omitempty"`
+	Value             *string         `json:"value,omitempty"`
+	BlockNumber       *int64          `json:"block_number,omitempty"`
+	BlockHash         *string         `json:"block_hash,omitempty"`
+	GasUsed           *int64          `json:"gas_used,omitempty"`
+	Confirmations     *int64          `json:"confirmations,omitempty"`
+	ErrorReason       *string         `json:"error_reason,omitempty"`
+	AttemptCount      int             `json:"attempt_count"`
+	LastError         *string         `json:"last_error,omitempty"`
+	NextAttemptAt     *time.Time      `json:"next_attempt_at,omitempty"`
+	SourceService     *string         `json:"source_service,omitempty"`
+	Metadata          json.RawMessage `json:"metadata"`
+	ExternalReference *string         `json:"external_reference,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// Event is one entry in a transaction's audit trail (ingestion_events)
+type Event struct {
+	ID             string    `json:"id"`
+	Seq            int64     `json:"seq"`
+	TransactionID  string    `json:"transaction_id"`
+	PreviousStatus *Status   `json:"previous_status,omitempty"`
+	NewStatus      Status    `json:"new_status"`
+	Reason         *string   `json:"reason,omitempty"`
+	Provider       *string   `json:"provider,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Set when events are listed across transactions, e.g. for the live stream
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	ChainID         int    `json:"chain_id,omitempty"`
+}
+
+// IsUUID reports whether s looks like a canonical UUID, the form of every
+// transaction, event and webhook ID. Callers use it to tell IDs from hashes
+// and to turn malformed IDs into a 404 rather than a Postgres cast error.
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, ch := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if ch != '-' {
+				return false
+			}
+		default:
+			if !('0' <= ch && ch <= '9') && !('a' <= ch && ch <= 'f') && !('A' <= ch && ch <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package store
+
+import "fmt"
+
+// conditions renders the filter as "AND ..." clauses over the transactions
+// table aliased as alias (empty for none), numbering placeholders after the
+// args already collected. statusColumn overrides the column Status matches.
+func (f Filter) conditions(alias, statusColumn string, args []interface{}) ([]string, []interface{}) {
+	col := func(name string) string {
+		if alias == "" {
+			return name
+		}
+		return alias + "." + name
+	}
+	if statusColumn == "" {
+		statusColumn = col("status")
+	}
+
+	var conditions []string
+	add := func(clause string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if f.FromAddress != "" {
+		add("AND "+col("from_address")+" = $%d", f.FromAddress)
+	}
+
+	if f.ToAddress != "" {
+		add("AND "+col("to_address")+" = $%d", f.ToAddress)
+	}
+
+	if f.ChainID != nil {
+		add("AND "+col("chain_id")+" = $%d", *f.ChainID)
+	}
+
+	if f.Status != "" {
+		add("AND "+statusColumn+" = $%d", f.Status)
+	}
+
+	if f.BlockNumberMin != nil {
+		add("AND "+col("block_number")+" >= $%d", *f.BlockNumberMin)
+	}
+
+	if f.BlockNumberMax != nil {
+		add("AND "+col("block_number")+" <= $%d", *f.BlockNumberMax)
+	}
+
+	if f.SourceService != "" {
+		add("AND "+col("source_service")+" = $%d", f.SourceService)
+	}
+
+	if f.ExternalReference != "" {
+		add("AND "+col("external_reference")+" = $%d", f.ExternalReference)
+	}
+
+	if len(f.Metadata) > 0 {
+		add("AND "+col("metadata")+" @> $%d::jsonb", string(f.Metadata))
+	}
+
+	return conditions, args
+}
@@ -0,0 +1,798 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+// MemoryStore is a goroutine-safe TransactionStore kept in process memory.
+// It mirrors PostgresStore's semantics so code can be tested without a database.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	txns   map[string]*memoryRecord
+	byHash map[memoryKey]string
+	events []models.Event
+
+	seq  int64
+	last time.Time
+}
+
+// memoryRecord is a transaction plus the bookkeeping columns the API never serves
+type memoryRecord struct {
+	txn models.Transaction
+
+	claimedBy      string
+	leaseExpiresAt *time.Time
+	pendingSince   *time.Time
+	nextCheckAt    *time.Time
+	checkCount     int
+}
+
+type memoryKey struct {
+	hash    string
+	chainID int
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		txns:   make(map[string]*memoryRecord),
+		byHash: make(map[memoryKey]string),
+	}
+}
+
+var _ TransactionStore = (*MemoryStore)(nil)
+
+// now returns a strictly increasing timestamp at the database's microsecond
+// precision, so creation order is never ambiguous
+func (s *MemoryStore) now() time.Time {
+	t := time.Now().UTC().Truncate(time.Microsecond)
+	if !t.After(s.last) {
+		t = s.last.Add(time.Microsecond)
+	}
+	s.last = t
+	return t
+}
+
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// clone copies the value behind p so the store never aliases caller memory
+func clone[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	return ptr(*p)
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// appendEvent records an event; callers hold mu
+func (s *MemoryStore) appendEvent(txID string, previousStatus, newStatus models.Status, reason, provider string, at time.Time) {
+	s.seq++
+	event := models.Event{
+		ID:            newUUID(),
+		Seq:           s.seq,
+		TransactionID: txID,
+		NewStatus:     newStatus,
+		Reason:        ptr(reason),
+		Provider:      nonEmpty(provider),
+		CreatedAt:     at,
+	}
+	if previousStatus != "" {
+		event.PreviousStatus = ptr(previousStatus)
+	}
+	s.events = append(s.events, event)
+}
+
+// insert adds a RECEIVED row unless one exists for the hash and chain; callers hold mu
+func (s *MemoryStore) insert(t NewTransaction, at time.Time) (*memoryRecord, bool, error) {
+	key := memoryKey{t.TransactionHash, t.ChainID}
+	if id, ok := s.byHash[key]; ok {
+		return s.txns[id], false, nil
+	}
+
+	metadata := json.RawMessage("{}")
+	if len(t.Metadata) > 0 {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(t.Metadata, &obj); err != nil {
+			return nil, false, fmt.Errorf("invalid metadata: %w", err)
+		}
+		metadata, _ = json.Marshal(obj)
+	}
+
+	rec := &memoryRecord{txn: models.Transaction{
+		ID:                newUUID(),
+		TransactionHash:   t.TransactionHash,
+		ChainID:           t.ChainID,
+		Status:            models.StatusReceived,
+		SourceService:     nonEmpty(t.SourceService),
+		Metadata:          metadata,
+		ExternalReference: nonEmpty(t.ExternalReference),
+		CreatedAt:         at,
+		UpdatedAt:         at,
+	}}
+	s.txns[rec.txn.ID] = rec
+	s.byHash[key] = rec.txn.ID
+	return rec, true, nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, t NewTransaction) (models.Transaction, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rec, created, err := s.insert(t, now)
+	if err != nil {
+		return models.Transaction{}, false, err
+	}
+
+	if created {
+		s.appendEvent(rec.txn.ID, "", rec.txn.Status, "transaction registered", "", now)
+	}
+	return rec.txn, created, nil
+}
+
+func (s *MemoryStore) CreateBatch(ctx context.Context, ts []NewTransaction) ([]CreateResult, error) {
+	if len(ts) == 0 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate first so a bad item leaves nothing behind, as a failed statement would
+	for _, t := range ts {
+		if len(t.Metadata) > 0 {
+			var obj map[string]interface{}
+			if err := json.Unmarshal(t.Metadata, &obj); err != nil {
+				return nil, fmt.Errorf("invalid metadata: %w", err)
+			}
+		}
+	}
+
+	now := s.now()
+	results := make([]CreateResult, len(ts))
+	for i, t := range ts {
+		rec, created, _ := s.insert(t, now)
+		if created {
+			s.appendEvent(rec.txn.ID, "", rec.txn.Status, "transaction registered", "", now)
+		}
+		results[i] = CreateResult{Transaction: rec.txn, Created: created}
+	}
+
+	return results, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.txns[id]
+	if !ok {
+		return models.Transaction{}, ErrNotFound
+	}
+	return rec.txn, nil
+}
+
+func (s *MemoryStore) FindByHash(ctx context.Context, hash string, chainID *int) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matches []models.Transaction
+	for _, rec := range s.txns {
+		if rec.txn.TransactionHash == hash && (chainID == nil || rec.txn.ChainID == *chainID) {
+			matches = append(matches, rec.txn)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ChainID < matches[j].ChainID })
+
+	return matches, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, q ListQuery) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transactions []models.Transaction
+	for _, rec := range s.txns {
+		if !q.Filter.matches(&rec.txn, rec.txn.Status) {
+			continue
+		}
+		if q.After != nil && !before(rec.txn, *q.After) {
+			continue
+		}
+		transactions = append(transactions, rec.txn)
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return before(transactions[j], Cursor{CreatedAt: transactions[i].CreatedAt, ID: transactions[i].ID})
+	})
+
+	offset := q.Offset
+	if q.After != nil {
+		offset = 0
+	}
+	if offset >= len(transactions) {
+		return nil, nil
+	}
+	transactions = transactions[offset:]
+	if len(transactions) > q.Limit {
+		transactions = transactions[:max(q.Limit, 0)]
+	}
+
+	return transactions, nil
+}
+
+// before reports whether txn sorts after c in (created_at DESC, id DESC) order
+func before(txn models.Transaction, c Cursor) bool {
+	if !txn.CreatedAt.Equal(c.CreatedAt) {
+		return txn.CreatedAt.Before(c.CreatedAt)
+	}
+	return txn.ID < c.ID
+}
+
+func (s *MemoryStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make(map[string]int)
+	for _, rec := range s.txns {
+		stats[string(rec.txn.Status)]++
+	}
+	return stats, nil
+}
+
+func (s *MemoryStore) Events(ctx context.Context, txID string) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []models.Event{}
+	for _, event := range s.events {
+		if event.TransactionID == txID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *MemoryStore) LatestEventSeq(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.seq, nil
+}
+
+func (s *MemoryStore) EventSeqs(ctx context.Context, after int64, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var seqs []int64
+	for _, event := range s.events {
+		if len(seqs) == limit {
+			break
+		}
+		if event.Seq > after {
+			seqs = append(seqs, event.Seq)
+		}
+	}
+	return seqs, nil
+}
+
+func (s *MemoryStore) ListEvents(ctx context.Context, q EventQuery) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []models.Event{}
+	for _, event := range s.events {
+		if event.Seq <= q.AfterSeq || event.Seq > q.UpToSeq {
+			continue
+		}
+
+		rec := s.txns[event.TransactionID]
+		if !q.Filter.matches(&rec.txn, event.NewStatus) {
+			continue
+		}
+
+		event.TransactionHash, event.ChainID = rec.txn.TransactionHash, rec.txn.ChainID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// sorted returns records matching keep, ordered by less
+func (s *MemoryStore) sorted(keep func(*memoryRecord) bool, less func(a, b *memoryRecord) bool) []*memoryRecord {
+	var recs []*memoryRecord
+	for _, rec := range s.txns {
+		if keep(rec) {
+			recs = append(recs, rec)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool { return less(recs[i], recs[j]) })
+	return recs
+}
+
+func byCreatedAt(a, b *memoryRecord) bool {
+	return a.txn.CreatedAt.Before(b.txn.CreatedAt)
+}
+
+func (s *MemoryStore) ClaimReceived(ctx context.Context, workerID string, limit int, lease time.Duration) ([]Claim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	due := s.sorted(func(rec *memoryRecord) bool {
+		return rec.txn.Status == models.StatusReceived && (rec.txn.NextAttemptAt == nil || !rec.txn.NextAttemptAt.After(now))
+	}, byCreatedAt)
+
+	var claimed []Claim
+	for _, rec := range due[:min(limit, len(due))] {
+		rec.txn.Status = models.StatusFetching
+		rec.claimedBy = workerID
+		rec.leaseExpiresAt = ptr(now.Add(lease))
+		rec.txn.AttemptCount++
+		rec.txn.NextAttemptAt = nil
+		rec.txn.UpdatedAt = now
+		s.appendEvent(rec.txn.ID, models.StatusReceived, models.StatusFetching, "claimed by worker "+workerID, "", now)
+
+		claimed = append(claimed, Claim{ID: rec.txn.ID, Hash: rec.txn.TransactionHash, ChainID: rec.txn.ChainID, Attempt: rec.txn.AttemptCount})
+	}
+
+	return claimed, nil
+}
+
+func (s *MemoryStore) RenewLeases(ctx context.Context, workerID string, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, rec := range s.txns {
+		if rec.claimedBy == workerID && slices.Contains(inFlightStatuses, rec.txn.Status) {
+			rec.leaseExpiresAt = ptr(now.Add(lease))
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) ReapExpiredLeases(ctx context.Context, maxAttempts int) ([]ReapedLease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var reaped []ReapedLease
+	for _, rec := range s.txns {
+		if !slices.Contains(inFlightStatuses, rec.txn.Status) || rec.leaseExpiresAt == nil || !rec.leaseExpiresAt.Before(now) {
+			continue
+		}
+
+		r := ReapedLease{
+			ID:             rec.txn.ID,
+			Hash:           rec.txn.TransactionHash,
+			PreviousStatus: rec.txn.Status,
+			ClaimedBy:      rec.claimedBy,
+			NewStatus:      models.StatusReceived,
+			Attempt:        rec.txn.AttemptCount,
+		}
+		worker := r.ClaimedBy
+		if worker == "" {
+			worker = "unknown"
+		}
+
+		reason := fmt.Sprintf("lease expired in %s (worker %s)", r.PreviousStatus, worker)
+		if r.Attempt >= maxAttempts {
+			r.NewStatus = models.StatusError
+			reason += fmt.Sprintf(" after %d attempts; dead-lettered", r.Attempt)
+			rec.txn.ErrorReason = ptr(reason)
+			rec.txn.LastError = ptr(reason)
+		} else {
+			reason += "; returned to RECEIVED"
+		}
+
+		rec.txn.Status = r.NewStatus
+		rec.txn.NextAttemptAt = nil
+		rec.txn.UpdatedAt = now
+		rec.release()
+		s.appendEvent(rec.txn.ID, r.PreviousStatus, r.NewStatus, reason, "", now)
+
+		reaped = append(reaped, r)
+	}
+
+	return reaped, nil
+}
+
+func (s *MemoryStore) ClaimDuePending(ctx context.Context, limit int, hold time.Duration) ([]PendingCheck, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	due := s.sorted(func(rec *memoryRecord) bool {
+		return rec.txn.Status == models.StatusPending && (rec.nextCheckAt == nil || !rec.nextCheckAt.After(now))
+	}, func(a, b *memoryRecord) bool {
+		// NULLS FIRST
+		if a.nextCheckAt == nil || b.nextCheckAt == nil {
+			return a.nextCheckAt == nil && b.nextCheckAt != nil
+		}
+		return a.nextCheckAt.Before(*b.nextCheckAt)
+	})
+
+	var checks []PendingCheck
+	for _, rec := range due[:min(limit, len(due))] {
+		rec.nextCheckAt = ptr(now.Add(hold))
+
+		since := rec.txn.UpdatedAt
+		if rec.pendingSince != nil {
+			since = *rec.pendingSince
+		}
+		checks = append(checks, PendingCheck{
+			ID:           rec.txn.ID,
+			Hash:         rec.txn.TransactionHash,
+			ChainID:      rec.txn.ChainID,
+			PendingSince: since,
+			CheckCount:   rec.checkCount,
+		})
+	}
+
+	return checks, nil
+}
+
+func (s *MemoryStore) ScheduleRecheck(ctx context.Context, id string, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.txns[id]; ok && rec.txn.Status == models.StatusPending {
+		rec.nextCheckAt = ptr(s.now().Add(delay))
+		rec.checkCount++
+	}
+	return nil
+}
+
+func mined(rec *memoryRecord) MinedTransaction {
+	m := MinedTransaction{ID: rec.txn.ID, Hash: rec.txn.TransactionHash, ChainID: rec.txn.ChainID, Status: rec.txn.Status}
+	if rec.txn.BlockNumber != nil {
+		m.BlockNumber = *rec.txn.BlockNumber
+	}
+	if rec.txn.BlockHash != nil {
+		m.BlockHash = *rec.txn.BlockHash
+	}
+	return m
+}
+
+func byBlockNumber(a, b *memoryRecord) bool {
+	return *a.txn.BlockNumber < *b.txn.BlockNumber
+}
+
+func (s *MemoryStore) ListIncluded(ctx context.Context, limit int) ([]MinedTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recs := s.sorted(func(rec *memoryRecord) bool {
+		return rec.txn.Status == models.StatusIncluded && rec.txn.BlockNumber != nil
+	}, byBlockNumber)
+
+	var included []MinedTransaction
+	for _, rec := range recs[:min(limit, len(recs))] {
+		included = append(included, mined(rec))
+	}
+	return included, nil
+}
+
+func (s *MemoryStore) SetConfirmations(ctx context.Context, id string, blockNumber, confirmations int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.txns[id]
+	if !ok {
+		return nil
+	}
+	switch rec.txn.Status {
+	case models.StatusIncluded, models.StatusConfirmed:
+	default:
+		return nil
+	}
+	if rec.txn.BlockNumber == nil || *rec.txn.BlockNumber != blockNumber {
+		return nil
+	}
+	if rec.txn.Confirmations != nil && *rec.txn.Confirmations == confirmations {
+		return nil
+	}
+
+	rec.txn.Confirmations = ptr(confirmations)
+	rec.txn.UpdatedAt = s.now()
+	return nil
+}
+
+func (s *MemoryStore) ListMinedSince(ctx context.Context, chainID int, fromBlock int64) ([]MinedTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recs := s.sorted(func(rec *memoryRecord) bool {
+		switch rec.txn.Status {
+		case models.StatusIncluded, models.StatusConfirmed, models.StatusFailed:
+		default:
+			return false
+		}
+		return rec.txn.ChainID == chainID && rec.txn.BlockHash != nil &&
+			rec.txn.BlockNumber != nil && *rec.txn.BlockNumber >= fromBlock
+	}, byBlockNumber)
+
+	var result []MinedTransaction
+	for _, rec := range recs {
+		result = append(result, mined(rec))
+	}
+	return result, nil
+}
+
+// change looks a row up for a status change, applying the same checks as
+// PostgresStore's conditional update; callers hold mu
+func (s *MemoryStore) change(id string, from, to models.Status, claimedBy string) (*memoryRecord, error) {
+	if err := models.ValidateTransition(from, to); err != nil {
+		return nil, err
+	}
+	if err := requireClaim(from, claimedBy); err != nil {
+		return nil, err
+	}
+	rec, ok := s.txns[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if rec.txn.Status != from || (claimedBy != "" && rec.claimedBy != claimedBy) {
+		return nil, &StatusConflictError{ID: id, Expected: from, Actual: rec.txn.Status, ExpectedClaim: claimedBy, ActualClaim: rec.claimedBy}
+	}
+	return rec, nil
+}
+
+// setChainData stores the normalized on-chain view of a transaction
+func (rec *memoryRecord) setChainData(data ChainData) {
+	rec.txn.FromAddress = ptr(data.FromAddress)
+	rec.txn.ToAddress = ptr(data.ToAddress)
+	rec.txn.Value = ptr(data.Value)
+	rec.txn.BlockNumber = clone(data.BlockNumber)
+	rec.txn.GasUsed = clone(data.GasUsed)
+	rec.txn.Confirmations = clone(data.Confirmations)
+	rec.txn.BlockHash = nonEmpty(data.BlockHash)
+}
+
+// release drops any claim held on the row
+func (rec *memoryRecord) release() {
+	rec.claimedBy = ""
+	rec.leaseExpiresAt = nil
+}
+
+func (s *MemoryStore) Transition(ctx context.Context, t Transition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.change(t.ID, t.From, t.To, t.ClaimedBy)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if t.ChainData != nil {
+		rec.setChainData(*t.ChainData)
+	}
+	rec.txn.Status = t.To
+	rec.txn.UpdatedAt = now
+	rec.txn.ErrorReason = nonEmpty(t.Reason)
+	if t.To == models.StatusPending {
+		if rec.pendingSince == nil {
+			rec.pendingSince = ptr(now)
+		}
+	} else {
+		rec.pendingSince = nil
+	}
+	rec.nextCheckAt = nil
+	rec.checkCount = 0
+	rec.release()
+
+	reason := t.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("Status changed by worker: %s → %s", t.From, t.To)
+	}
+	s.appendEvent(t.ID, t.From, t.To, reason, t.Provider, now)
+	return nil
+}
+
+func (s *MemoryStore) ScheduleRetry(ctx context.Context, r Retry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.change(r.ID, r.From, models.StatusReceived, r.ClaimedBy)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	rec.txn.Status = models.StatusReceived
+	rec.txn.LastError = ptr(r.LastError)
+	rec.txn.NextAttemptAt = ptr(now.Add(r.Delay))
+	if r.RefundAttempt {
+		rec.txn.AttemptCount = max(rec.txn.AttemptCount-1, 0)
+	}
+	rec.txn.UpdatedAt = now
+	rec.release()
+
+	s.appendEvent(r.ID, r.From, models.StatusReceived, r.Reason, r.Provider, now)
+	return nil
+}
+
+func (s *MemoryStore) DeadLetter(ctx context.Context, t Transition, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.change(t.ID, t.From, models.StatusError, t.ClaimedBy)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	rec.txn.Status = models.StatusError
+	rec.txn.ErrorReason = nonEmpty(t.Reason)
+	rec.txn.LastError = ptr(lastError)
+	rec.txn.NextAttemptAt = nil
+	rec.txn.UpdatedAt = now
+	rec.release()
+
+	s.appendEvent(t.ID, t.From, models.StatusError, t.Reason, t.Provider, now)
+	return nil
+}
+
+func (s *MemoryStore) Requeue(ctx context.Context, t Transition) error {
+	if err := validateRequeue(t.From); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.change(t.ID, t.From, models.StatusReceived, "")
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	rec.txn.Status = models.StatusReceived
+	rec.txn.ErrorReason = nil
+	rec.txn.AttemptCount = 0
+	rec.txn.NextAttemptAt = nil
+	rec.txn.UpdatedAt = now
+	rec.pendingSince = nil
+	rec.nextCheckAt = nil
+	rec.checkCount = 0
+
+	reason := t.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("requeued by operator from %s", t.From)
+	}
+	s.appendEvent(t.ID, t.From, models.StatusReceived, reason, t.Provider, now)
+	return nil
+}
+
+func (s *MemoryStore) ResetForReorg(ctx context.Context, t Transition, orphanedBlockHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.change(t.ID, t.From, models.StatusReceived, "")
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if rec.txn.BlockHash == nil || *rec.txn.BlockHash != orphanedBlockHash {
+		return false, nil
+	}
+
+	now := s.now()
+	rec.txn.Status = models.StatusReceived
+	rec.txn.BlockNumber = nil
+	rec.txn.BlockHash = nil
+	rec.txn.GasUsed = nil
+	rec.txn.Confirmations = nil
+	rec.txn.ErrorReason = nil
+	rec.txn.AttemptCount = 0
+	rec.txn.NextAttemptAt = nil
+	rec.txn.UpdatedAt = now
+
+	s.appendEvent(t.ID, t.From, models.StatusReceived, t.Reason, t.Provider, now)
+	return true, nil
+}
+
+// matches reports whether a row passes the filter. status is the value Status
+// is compared against: the row's own, or an event's new status.
+func (f Filter) matches(txn *models.Transaction, status models.Status) bool {
+	eq := func(want string, got *string) bool {
+		return want == "" || (got != nil && *got == want)
+	}
+
+	if !eq(f.FromAddress, txn.FromAddress) || !eq(f.ToAddress, txn.ToAddress) ||
+		!eq(f.SourceService, txn.SourceService) || !eq(f.ExternalReference, txn.ExternalReference) {
+		return false
+	}
+
+	if f.ChainID != nil && txn.ChainID != *f.ChainID {
+		return false
+	}
+
+	if f.Status != "" && status != f.Status {
+		return false
+	}
+
+	if f.BlockNumberMin != nil && (txn.BlockNumber == nil || *txn.BlockNumber < *f.BlockNumberMin) {
+		return false
+	}
+
+	if f.BlockNumberMax != nil && (txn.BlockNumber == nil || *txn.BlockNumber > *f.BlockNumberMax) {
+		return false
+	}
+
+	if len(f.Metadata) > 0 {
+		var have, want interface{}
+		if json.Unmarshal(txn.Metadata, &have) != nil || json.Unmarshal(f.Metadata, &want) != nil {
+			return false
+		}
+		if !jsonContains(have, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jsonContains implements jsonb's @> for decoded JSON values
+func jsonContains(have, want interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		h, ok := have.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, wv := range w {
+			hv, ok := h[k]
+			if !ok || !jsonContains(hv, wv) {
+				return false
+			}
+		}
+		return true
+
+	case []interface{}:
+		h, ok := have.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, wv := range w {
+			if !slices.ContainsFunc(h, func(hv interface{}) bool { return jsonContains(hv, wv) }) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return reflect.DeepEqual(have, want)
+	}
+}
@@ -0,0 +1,14 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/Wuzu11517/TxnFlow/internal/store/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.TransactionStore {
+		return store.NewMemoryStore()
+	})
+}
@@ -0,0 +1,892 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/db"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TransitionHook runs inside the database transaction that records a status
+// change, so whatever it writes commits or rolls back with the change
+type TransitionHook func(ctx context.Context, tx pgx.Tx, event models.Event) error
+
+// PostgresStore implements TransactionStore on pgx
+type PostgresStore struct {
+	DB *pgxpool.Pool
+
+	// OnTransition, when set, is called for every status change
+	OnTransition TransitionHook
+}
+
+// NewPostgresStore creates a store on the given pool
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+var _ TransactionStore = (*PostgresStore)(nil)
+
+// transactionColumns are selected, in order, by scanTransaction
+const transactionColumns = `
+	id,
+	transaction_hash,
+	chain_id,
+	status,
+	from_address,
+	to_address,
+	value,
+	block_number,
+	block_hash,
+	gas_used,
+	confirmations,
+	error_reason,
+	attempt_count,
+	last_error,
+	next_attempt_at,
+	source_service,
+	metadata,
+	external_reference,
+	created_at,
+	updated_at
+`
+
+func scanTransaction(row pgx.Row, txn *models.Transaction) error {
+	return row.Scan(
+		&txn.ID,
+		&txn.TransactionHash,
+		&txn.ChainID,
+		&txn.Status,
+		&txn.FromAddress,
+		&txn.ToAddress,
+		&txn.Value,
+		&txn.BlockNumber,
+		&txn.BlockHash,
+		&txn.GasUsed,
+		&txn.Confirmations,
+		&txn.ErrorReason,
+		&txn.AttemptCount,
+		&txn.LastError,
+		&txn.NextAttemptAt,
+		&txn.SourceService,
+		&txn.Metadata,
+		&txn.ExternalReference,
+		&txn.CreatedAt,
+		&txn.UpdatedAt,
+	)
+}
+
+func (s *PostgresStore) queryTransactions(ctx context.Context, query string, args ...interface{}) ([]models.Transaction, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var txn models.Transaction
+		if err := scanTransaction(rows, &txn); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+
+	return transactions, rows.Err()
+}
+
+func metadataOrEmpty(t NewTransaction) string {
+	if len(t.Metadata) == 0 {
+		return "{}"
+	}
+	return string(t.Metadata)
+}
+
+func (s *PostgresStore) Create(ctx context.Context, t NewTransaction) (models.Transaction, bool, error) {
+	var txn models.Transaction
+
+	//idempotent insert
+	insertQuery := `
+		INSERT INTO transactions (
+			transaction_hash, chain_id, status, source_service, metadata, external_reference, created_at, updated_at
+		)
+		VALUES ($1, $2, 'RECEIVED', NULLIF($3, ''), $4::jsonb, NULLIF($5, ''), now(), now())
+		ON CONFLICT (transaction_hash, chain_id)
+		DO NOTHING
+		RETURNING ` + transactionColumns
+
+	err := scanTransaction(s.DB.QueryRow(ctx, insertQuery,
+		t.TransactionHash, t.ChainID, t.SourceService, metadataOrEmpty(t), t.ExternalReference,
+	), &txn)
+	created := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return txn, false, err
+	}
+
+	//fetch existing row if insert didn't succeed
+	if !created {
+		selectQuery := `SELECT ` + transactionColumns + ` FROM transactions WHERE transaction_hash = $1 AND chain_id = $2`
+		if err := scanTransaction(s.DB.QueryRow(ctx, selectQuery, t.TransactionHash, t.ChainID), &txn); err != nil {
+			return txn, false, err
+		}
+	}
+
+	if created {
+		//store ingestion event; a resubmission isn't a new registration
+		_, _ = s.DB.Exec(
+			ctx,
+			`INSERT INTO ingestion_events (transaction_id, new_status, reason)
+			 VALUES ($1, $2, $3)`,
+			txn.ID,
+			txn.Status,
+			"transaction registered",
+		)
+
+		//wake the worker so it doesn't wait for its next poll
+		_, _ = s.DB.Exec(ctx, `SELECT pg_notify($1, $2)`, db.TransactionsChannel, txn.ID)
+	}
+
+	return txn, created, nil
+}
+
+func (s *PostgresStore) CreateBatch(ctx context.Context, ts []NewTransaction) ([]CreateResult, error) {
+	if len(ts) == 0 {
+		return nil, nil
+	}
+
+	hashes := make([]string, len(ts))
+	chainIDs := make([]int, len(ts))
+	sources := make([]string, len(ts))
+	metadata := make([]string, len(ts))
+	references := make([]string, len(ts))
+	for i, t := range ts {
+		hashes[i], chainIDs[i] = t.TransactionHash, t.ChainID
+		sources[i], metadata[i], references[i] = t.SourceService, metadataOrEmpty(t), t.ExternalReference
+	}
+
+	// The main query can't see rows it inserts itself, nor ones committed by
+	// a concurrent insert after its snapshot; the follow-up query catches those
+	query := `
+		WITH input AS (
+			SELECT DISTINCT ON (transaction_hash, chain_id) *
+			FROM unnest($1::text[], $2::integer[], $3::text[], $4::text[], $5::text[])
+				AS i(transaction_hash, chain_id, source_service, metadata, external_reference)
+		),
+		inserted AS (
+			INSERT INTO transactions (
+				transaction_hash, chain_id, status, source_service, metadata, external_reference, created_at, updated_at
+			)
+			SELECT transaction_hash, chain_id, 'RECEIVED', NULLIF(source_service, ''), metadata::jsonb,
+				NULLIF(external_reference, ''), now(), now()
+			FROM input
+			ON CONFLICT (transaction_hash, chain_id)
+			DO NOTHING
+			RETURNING ` + transactionColumns + `
+		),
+		events AS (
+			INSERT INTO ingestion_events (transaction_id, new_status, reason)
+			SELECT id, status, 'transaction registered'
+			FROM inserted
+		)
+		SELECT ` + transactionColumns + `, true FROM inserted
+		UNION ALL
+		SELECT ` + prefixColumns("t") + `, false
+		FROM transactions AS t
+		JOIN input AS i ON i.transaction_hash = t.transaction_hash AND i.chain_id = t.chain_id
+	`
+
+	type key struct {
+		hash    string
+		chainID int
+	}
+	found := make(map[key]CreateResult, len(ts))
+
+	collect := func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var r CreateResult
+			txn := &r.Transaction
+			err := rows.Scan(
+				&txn.ID, &txn.TransactionHash, &txn.ChainID, &txn.Status, &txn.FromAddress, &txn.ToAddress,
+				&txn.Value, &txn.BlockNumber, &txn.BlockHash, &txn.GasUsed, &txn.Confirmations, &txn.ErrorReason,
+				&txn.AttemptCount, &txn.LastError, &txn.NextAttemptAt, &txn.SourceService, &txn.Metadata,
+				&txn.ExternalReference, &txn.CreatedAt, &txn.UpdatedAt, &r.Created,
+			)
+			if err != nil {
+				return err
+			}
+			found[key{txn.TransactionHash, txn.ChainID}] = r
+		}
+		return rows.Err()
+	}
+
+	rows, err := s.DB.Query(ctx, query, hashes, chainIDs, sources, metadata, references)
+	if err != nil {
+		return nil, err
+	}
+	if err := collect(rows); err != nil {
+		return nil, err
+	}
+
+	var missingHashes []string
+	var missingChains []int
+	for _, t := range ts {
+		if _, ok := found[key{t.TransactionHash, t.ChainID}]; !ok {
+			missingHashes = append(missingHashes, t.TransactionHash)
+			missingChains = append(missingChains, t.ChainID)
+		}
+	}
+
+	if len(missingHashes) > 0 {
+		rows, err := s.DB.Query(ctx, `
+			SELECT `+prefixColumns("t")+`, false
+			FROM transactions AS t
+			JOIN unnest($1::text[], $2::integer[]) AS i(transaction_hash, chain_id)
+				ON i.transaction_hash = t.transaction_hash AND i.chain_id = t.chain_id
+		`, missingHashes, missingChains)
+		if err != nil {
+			return nil, err
+		}
+		if err := collect(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	// A repeated item resolves to its first occurrence, which alone counts as created
+	results := make([]CreateResult, len(ts))
+	seen := make(map[key]bool, len(ts))
+	var firstCreated string
+	for i, t := range ts {
+		k := key{t.TransactionHash, t.ChainID}
+		r, ok := found[k]
+		if !ok {
+			return nil, fmt.Errorf("transaction %s on chain %d vanished during batch insert", t.TransactionHash, t.ChainID)
+		}
+		if seen[k] {
+			r.Created = false
+		}
+		seen[k] = true
+
+		if r.Created && firstCreated == "" {
+			firstCreated = r.Transaction.ID
+		}
+		results[i] = r
+	}
+
+	//one wake-up for the whole batch
+	if firstCreated != "" {
+		_, _ = s.DB.Exec(ctx, `SELECT pg_notify($1, $2)`, db.TransactionsChannel, firstCreated)
+	}
+
+	return results, nil
+}
+
+// prefixColumns qualifies transactionColumns with a table alias
+func prefixColumns(alias string) string {
+	fields := strings.Split(transactionColumns, ",")
+	for i, f := range fields {
+		fields[i] = alias + "." + strings.TrimSpace(f)
+	}
+	return strings.Join(fields, ", ")
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (models.Transaction, error) {
+	var txn models.Transaction
+
+	err := scanTransaction(s.DB.QueryRow(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, id), &txn)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return txn, ErrNotFound
+	}
+	return txn, err
+}
+
+func (s *PostgresStore) FindByHash(ctx context.Context, hash string, chainID *int) ([]models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE transaction_hash = $1 AND ($2::integer IS NULL OR chain_id = $2)
+		ORDER BY chain_id
+	`
+	return s.queryTransactions(ctx, query, hash, chainID)
+}
+
+func (s *PostgresStore) List(ctx context.Context, q ListQuery) ([]models.Transaction, error) {
+	conditions, args := q.Filter.conditions("", "", nil)
+
+	offset := q.Offset
+	if q.After != nil {
+		args = append(args, q.After.CreatedAt, q.After.ID)
+		conditions = append(conditions, fmt.Sprintf("AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+		offset = 0
+	}
+	argCounter := len(args) + 1
+
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE 1=1 ` + strings.Join(conditions, " ") +
+		fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argCounter, argCounter+1)
+	args = append(args, q.Limit, offset)
+
+	return s.queryTransactions(ctx, query, args...)
+}
+
+func (s *PostgresStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := s.DB.Query(ctx, `SELECT status, COUNT(*) FROM transactions GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats[status] = count
+	}
+
+	return stats, rows.Err()
+}
+
+// eventColumns are selected, in order, by scanEvent
+const eventColumns = `e.id, e.seq, e.transaction_id, e.previous_status, e.new_status, e.reason, e.provider, e.created_at`
+
+func scanEvent(row pgx.Row, event *models.Event, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{
+		&event.ID,
+		&event.Seq,
+		&event.TransactionID,
+		&event.PreviousStatus,
+		&event.NewStatus,
+		&event.Reason,
+		&event.Provider,
+		&event.CreatedAt,
+	}, extra...)...)
+}
+
+func (s *PostgresStore) Events(ctx context.Context, txID string) ([]models.Event, error) {
+	rows, err := s.DB.Query(ctx, `
+		SELECT `+eventColumns+`
+		FROM ingestion_events AS e
+		WHERE e.transaction_id = $1
+		ORDER BY e.seq
+	`, txID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		var event models.Event
+		if err := scanEvent(rows, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *PostgresStore) LatestEventSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := s.DB.QueryRow(ctx, `SELECT COALESCE(max(seq), 0) FROM ingestion_events`).Scan(&seq)
+	return seq, err
+}
+
+func (s *PostgresStore) EventSeqs(ctx context.Context, after int64, limit int) ([]int64, error) {
+	rows, err := s.DB.Query(ctx, `SELECT seq FROM ingestion_events WHERE seq > $1 ORDER BY seq LIMIT $2`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seqs []int64
+	for rows.Next() {
+		var seq int64
+		if err := rows.Scan(&seq); err != nil {
+			return nil, err
+		}
+		seqs = append(seqs, seq)
+	}
+
+	return seqs, rows.Err()
+}
+
+func (s *PostgresStore) ListEvents(ctx context.Context, q EventQuery) ([]models.Event, error) {
+	conditions, args := q.Filter.conditions("t", "e.new_status::text", []interface{}{q.AfterSeq, q.UpToSeq})
+
+	query := `
+		SELECT ` + eventColumns + `, t.transaction_hash, t.chain_id
+		FROM ingestion_events AS e
+		JOIN transactions AS t ON t.id = e.transaction_id
+		WHERE e.seq > $1 AND e.seq <= $2
+		` + strings.Join(conditions, " ") + `
+		ORDER BY e.seq
+	`
+
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		var event models.Event
+		if err := scanEvent(rows, &event, &event.TransactionHash, &event.ChainID); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ClaimReceived leases due RECEIVED rows. SKIP LOCKED lets concurrent workers
+// claim disjoint rows instead of blocking on (or double-processing) each other's.
+func (s *PostgresStore) ClaimReceived(ctx context.Context, workerID string, limit int, lease time.Duration) ([]Claim, error) {
+	query := `
+		WITH candidates AS (
+			SELECT id, status
+			FROM transactions
+			WHERE status = 'RECEIVED'
+			  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+			ORDER BY created_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE transactions AS t
+			SET status = 'FETCHING',
+				claimed_by = $2,
+				lease_expires_at = now() + $3 * interval '1 millisecond',
+				attempt_count = t.attempt_count + 1,
+				next_attempt_at = NULL,
+				updated_at = now()
+			FROM candidates
+			WHERE t.id = candidates.id
+			RETURNING t.id, t.transaction_hash, t.chain_id, t.attempt_count, t.created_at, candidates.status AS previous_status
+		), events AS (
+			INSERT INTO ingestion_events (transaction_id, previous_status, new_status, reason)
+			SELECT id, previous_status, 'FETCHING', 'claimed by worker ' || $2
+			FROM claimed
+		)
+		SELECT id, transaction_hash, chain_id, attempt_count
+		FROM claimed
+		ORDER BY created_at ASC
+	`
+
+	rows, err := s.DB.Query(ctx, query, limit, workerID, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []Claim
+	for rows.Next() {
+		var c Claim
+		if err := rows.Scan(&c.ID, &c.Hash, &c.ChainID, &c.Attempt); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, c)
+	}
+
+	return claimed, rows.Err()
+}
+
+func (s *PostgresStore) RenewLeases(ctx context.Context, workerID string, lease time.Duration) error {
+	query := `
+		UPDATE transactions
+		SET lease_expires_at = now() + $2 * interval '1 millisecond'
+		WHERE claimed_by = $1 AND status::text = ANY($3)
+	`
+
+	_, err := s.DB.Exec(ctx, query, workerID, lease.Milliseconds(), inFlightStatuses)
+	return err
+}
+
+// ReapExpiredLeases returns abandoned rows to RECEIVED, or to ERROR once their
+// claims have used up maxAttempts, so a row that keeps killing its worker ends
+// up dead-lettered instead of being reclaimed forever
+func (s *PostgresStore) ReapExpiredLeases(ctx context.Context, maxAttempts int) ([]ReapedLease, error) {
+	query := `
+		WITH expired AS (
+			SELECT id, status, claimed_by, attempt_count >= $2 AS exhausted,
+				'lease expired in ' || status::text || ' (worker ' || COALESCE(claimed_by, 'unknown') || ')' ||
+				CASE WHEN attempt_count >= $2
+					THEN ' after ' || attempt_count || ' attempts; dead-lettered'
+					ELSE '; returned to RECEIVED'
+				END AS reason
+			FROM transactions
+			WHERE status::text = ANY($1)
+			  AND lease_expires_at < now()
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE transactions AS t
+		SET status = CASE WHEN expired.exhausted THEN 'ERROR' ELSE 'RECEIVED' END::transaction_status,
+			error_reason = CASE WHEN expired.exhausted THEN expired.reason ELSE t.error_reason END,
+			last_error = CASE WHEN expired.exhausted THEN expired.reason ELSE t.last_error END,
+			next_attempt_at = NULL,
+			claimed_by = NULL,
+			lease_expires_at = NULL,
+			updated_at = now()
+		FROM expired
+		WHERE t.id = expired.id
+		RETURNING t.id, t.transaction_hash, expired.status::text, COALESCE(expired.claimed_by, ''),
+			t.status::text, t.attempt_count, expired.reason
+	`
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, inFlightStatuses, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		reaped  []ReapedLease
+		reasons []string
+	)
+	for rows.Next() {
+		var r ReapedLease
+		var reason string
+		if err := rows.Scan(&r.ID, &r.Hash, &r.PreviousStatus, &r.ClaimedBy, &r.NewStatus, &r.Attempt, &reason); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		reaped = append(reaped, r)
+		reasons = append(reasons, reason)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Subscribers hear about dead-letters, not about rows going back in the queue
+	for i, r := range reaped {
+		notify := r.NewStatus == models.StatusError
+		if err := s.recordEvent(ctx, tx, notify, r.ID, r.PreviousStatus, r.NewStatus, reasons[i], ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return reaped, tx.Commit(ctx)
+}
+
+// ClaimDuePending pushes next_check_at out by hold so other replicas skip the
+// rows while this worker polls the node
+func (s *PostgresStore) ClaimDuePending(ctx context.Context, limit int, hold time.Duration) ([]PendingCheck, error) {
+	query := `
+		UPDATE transactions AS t
+		SET next_check_at = now() + $2 * interval '1 millisecond'
+		FROM (
+			SELECT id
+			FROM transactions
+			WHERE status = 'PENDING'
+			  AND (next_check_at IS NULL OR next_check_at <= now())
+			ORDER BY next_check_at ASC NULLS FIRST
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) AS due
+		WHERE t.id = due.id
+		RETURNING t.id, t.transaction_hash, t.chain_id, COALESCE(t.pending_since, t.updated_at), t.check_count
+	`
+
+	rows, err := s.DB.Query(ctx, query, limit, hold.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []PendingCheck
+	for rows.Next() {
+		var p PendingCheck
+		if err := rows.Scan(&p.ID, &p.Hash, &p.ChainID, &p.PendingSince, &p.CheckCount); err != nil {
+			return nil, err
+		}
+		due = append(due, p)
+	}
+
+	return due, rows.Err()
+}
+
+func (s *PostgresStore) ScheduleRecheck(ctx context.Context, id string, delay time.Duration) error {
+	query := `
+		UPDATE transactions
+		SET next_check_at = now() + $1 * interval '1 millisecond', check_count = check_count + 1
+		WHERE id = $2 AND status = 'PENDING'
+	`
+
+	_, err := s.DB.Exec(ctx, query, delay.Milliseconds(), id)
+	return err
+}
+
+func (s *PostgresStore) queryMined(ctx context.Context, query string, args ...interface{}) ([]MinedTransaction, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mined []MinedTransaction
+	for rows.Next() {
+		var m MinedTransaction
+		if err := rows.Scan(&m.ID, &m.Hash, &m.ChainID, &m.Status, &m.BlockNumber, &m.BlockHash); err != nil {
+			return nil, err
+		}
+		mined = append(mined, m)
+	}
+
+	return mined, rows.Err()
+}
+
+func (s *PostgresStore) ListIncluded(ctx context.Context, limit int) ([]MinedTransaction, error) {
+	query := `
+		SELECT id, transaction_hash, chain_id, status, block_number, COALESCE(block_hash, '')
+		FROM transactions
+		WHERE status = 'INCLUDED' AND block_number IS NOT NULL
+		ORDER BY block_number ASC
+		LIMIT $1
+	`
+	return s.queryMined(ctx, query, limit)
+}
+
+func (s *PostgresStore) SetConfirmations(ctx context.Context, id string, blockNumber, confirmations int64) error {
+	_, err := s.DB.Exec(ctx, `
+		UPDATE transactions
+		SET confirmations = $2, updated_at = now()
+		WHERE id = $1
+		  AND status IN ('INCLUDED', 'CONFIRMED')
+		  AND block_number = $3
+		  AND confirmations IS DISTINCT FROM $2
+	`, id, confirmations, blockNumber)
+	return err
+}
+
+func (s *PostgresStore) ListMinedSince(ctx context.Context, chainID int, fromBlock int64) ([]MinedTransaction, error) {
+	query := `
+		SELECT id, transaction_hash, chain_id, status, block_number, block_hash
+		FROM transactions
+		WHERE chain_id = $1
+		  AND block_hash IS NOT NULL
+		  AND block_number >= $2
+		  AND status IN ('INCLUDED', 'CONFIRMED', 'FAILED')
+		ORDER BY block_number ASC
+	`
+	return s.queryMined(ctx, query, chainID, fromBlock)
+}
+
+// recordEvent inserts the event for a status change and, when notify is set,
+// runs the transition hook
+func (s *PostgresStore) recordEvent(ctx context.Context, tx pgx.Tx, notify bool, txID string, previousStatus, newStatus models.Status, reason, provider string) error {
+	event := models.Event{
+		TransactionID:  txID,
+		PreviousStatus: &previousStatus,
+		NewStatus:      newStatus,
+		Reason:         &reason,
+	}
+	if provider != "" {
+		event.Provider = &provider
+	}
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO ingestion_events (transaction_id, previous_status, new_status, reason, provider)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, seq, created_at
+	`, txID, previousStatus, newStatus, reason, provider).Scan(&event.ID, &event.Seq, &event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if notify && s.OnTransition != nil {
+		if err := s.OnTransition(ctx, tx, event); err != nil {
+			return fmt.Errorf("transition hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// conflict explains why a conditional update matched no row: the row is gone,
+// its status is no longer the expected one, or another worker claimed it
+func conflict(ctx context.Context, tx pgx.Tx, id string, expected models.Status, claimedBy string) error {
+	var actual models.Status
+	var actualClaim string
+	err := tx.QueryRow(ctx, `SELECT status, COALESCE(claimed_by, '') FROM transactions WHERE id = $1`, id).Scan(&actual, &actualClaim)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return &StatusConflictError{ID: id, Expected: expected, Actual: actual, ExpectedClaim: claimedBy, ActualClaim: actualClaim}
+}
+
+// changeStatus runs a conditional UPDATE of row id, whose first two parameters
+// must be the ID and the expected status, and records the event. Rows in any
+// other status are left alone, so concurrent writers can't overwrite each
+// other's changes. Queries leaving an in-flight status must also match
+// claimed_by against claimedBy.
+func (s *PostgresStore) changeStatus(ctx context.Context, notify bool, id string, from, to models.Status, claimedBy, reason, provider, query string, args ...interface{}) error {
+	if err := models.ValidateTransition(from, to); err != nil {
+		return err
+	}
+	if err := requireClaim(from, claimedBy); err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return conflict(ctx, tx, id, from, claimedBy)
+	}
+
+	if err := s.recordEvent(ctx, tx, notify, id, from, to, reason, provider); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Transition updates the status, tracking when a row first became PENDING
+// and releasing any claim held on it. Chain data is written by the same
+// conditional UPDATE, so it never lands on a row this change lost.
+func (s *PostgresStore) Transition(ctx context.Context, t Transition) error {
+	reason := t.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("Status changed by worker: %s → %s", t.From, t.To)
+	}
+
+	args := []interface{}{t.ID, t.From, t.To, t.Reason, t.ClaimedBy}
+	var setChainData string
+	if d := t.ChainData; d != nil {
+		setChainData = `,
+			from_address = $6,
+			to_address = $7,
+			value = $8,
+			block_number = $9,
+			gas_used = $10,
+			confirmations = $11,
+			block_hash = NULLIF($12, '')`
+		args = append(args, d.FromAddress, d.ToAddress, d.Value, d.BlockNumber, d.GasUsed, d.Confirmations, d.BlockHash)
+	}
+
+	return s.changeStatus(ctx, true, t.ID, t.From, t.To, t.ClaimedBy, reason, t.Provider, `
+		UPDATE transactions
+		SET status = $3, updated_at = now(), error_reason = NULLIF($4, ''),
+			pending_since = CASE WHEN $3 = 'PENDING' THEN COALESCE(pending_since, now()) ELSE NULL END,
+			next_check_at = NULL,
+			check_count = 0,
+			claimed_by = NULL,
+			lease_expires_at = NULL`+setChainData+`
+		WHERE id = $1 AND status = $2 AND ($5 = '' OR claimed_by = $5)
+	`, args...)
+}
+
+func (s *PostgresStore) ScheduleRetry(ctx context.Context, r Retry) error {
+	// Retries are internal churn, subscribers only hear about the outcome
+	return s.changeStatus(ctx, false, r.ID, r.From, models.StatusReceived, r.ClaimedBy, r.Reason, r.Provider, `
+		UPDATE transactions
+		SET status = 'RECEIVED',
+			last_error = $3,
+			next_attempt_at = now() + $4 * interval '1 millisecond',
+			attempt_count = CASE WHEN $6 THEN GREATEST(attempt_count - 1, 0) ELSE attempt_count END,
+			claimed_by = NULL,
+			lease_expires_at = NULL,
+			updated_at = now()
+		WHERE id = $1 AND status = $2 AND ($5 = '' OR claimed_by = $5)
+	`, r.ID, r.From, r.LastError, r.Delay.Milliseconds(), r.ClaimedBy, r.RefundAttempt)
+}
+
+func (s *PostgresStore) DeadLetter(ctx context.Context, t Transition, lastError string) error {
+	return s.changeStatus(ctx, true, t.ID, t.From, models.StatusError, t.ClaimedBy, t.Reason, t.Provider, `
+		UPDATE transactions
+		SET status = 'ERROR',
+			error_reason = NULLIF($4, ''),
+			last_error = $3,
+			next_attempt_at = NULL,
+			claimed_by = NULL,
+			lease_expires_at = NULL,
+			updated_at = now()
+		WHERE id = $1 AND status = $2 AND ($5 = '' OR claimed_by = $5)
+	`, t.ID, t.From, lastError, t.Reason, t.ClaimedBy)
+}
+
+// Requeue resets the retry bookkeeping so the row gets MaxAttempts again,
+// keeping last_error for reference
+func (s *PostgresStore) Requeue(ctx context.Context, t Transition) error {
+	if err := validateRequeue(t.From); err != nil {
+		return err
+	}
+
+	reason := t.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("requeued by operator from %s", t.From)
+	}
+
+	err := s.changeStatus(ctx, true, t.ID, t.From, models.StatusReceived, "", reason, t.Provider, `
+		UPDATE transactions
+		SET status = 'RECEIVED',
+			error_reason = NULL,
+			attempt_count = 0,
+			next_attempt_at = NULL,
+			pending_since = NULL,
+			next_check_at = NULL,
+			check_count = 0,
+			updated_at = now()
+		WHERE id = $1 AND status = $2
+	`, t.ID, t.From)
+	if err != nil {
+		return err
+	}
+
+	//wake the worker so it doesn't wait for its next poll
+	_, _ = s.DB.Exec(ctx, `SELECT pg_notify($1, $2)`, db.TransactionsChannel, t.ID)
+	return nil
+}
+
+// ResetForReorg clears the block data that came from the orphaned block. The
+// block_hash guard makes it a no-op if the row has been re-fetched meanwhile.
+func (s *PostgresStore) ResetForReorg(ctx context.Context, t Transition, orphanedBlockHash string) (bool, error) {
+	err := s.changeStatus(ctx, true, t.ID, t.From, models.StatusReceived, "", t.Reason, t.Provider, `
+		UPDATE transactions
+		SET status = 'RECEIVED',
+			block_number = NULL,
+			block_hash = NULL,
+			gas_used = NULL,
+			confirmations = NULL,
+			error_reason = NULL,
+			attempt_count = 0,
+			next_attempt_at = NULL,
+			updated_at = now()
+		WHERE id = $1 AND status = $2 AND block_hash = $3
+	`, t.ID, t.From, orphanedBlockHash)
+
+	// A gone row or a changed block hash means there is nothing left to undo
+	var conflictErr *StatusConflictError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return false, nil
+	case errors.As(err, &conflictErr) && conflictErr.Actual == t.From:
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
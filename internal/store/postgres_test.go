@@ -0,0 +1,38 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/db"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+	"github.com/Wuzu11517/TxnFlow/internal/store/storetest"
+)
+
+// TestPostgresStore migrates TEST_DATABASE_URL and runs against it, deleting
+// its transactions. It is skipped when the variable is unset.
+func TestPostgresStore(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := db.NewMigrator(pool).Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	storetest.Run(t, func(t *testing.T) store.TransactionStore {
+		if _, err := pool.Exec(ctx, `TRUNCATE transactions, ingestion_events RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return store.NewPostgresStore(pool)
+	})
+}
@@ -0,0 +1,1064 @@
+// Package storetest is a conformance suite for store.TransactionStore
+// implementations. Every implementation must pass it, so code tested against
+// the in-memory store behaves the same on Postgres.
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// missingID is a well-formed UUID that no store will have handed out
+const missingID = "00000000-0000-4000-8000-000000000000"
+
+// Run runs the suite. newStore must return an empty store for each subtest.
+func Run(t *testing.T, newStore func(t *testing.T) store.TransactionStore) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.TransactionStore)
+	}{
+		{"CreateIsIdempotent", testCreateIsIdempotent},
+		{"CreateMetadata", testCreateMetadata},
+		{"CreateConcurrent", testCreateConcurrent},
+		{"CreateBatch", testCreateBatch},
+		{"GetAndFindByHash", testGetAndFindByHash},
+		{"ListFilters", testListFilters},
+		{"ListPagination", testListPagination},
+		{"CountByStatus", testCountByStatus},
+		{"Transition", testTransition},
+		{"TransitionErrors", testTransitionErrors},
+		{"ClaimAndRetry", testClaimAndRetry},
+		{"DeadLetter", testDeadLetter},
+		{"Requeue", testRequeue},
+		{"Leases", testLeases},
+		{"ReclaimedLease", testReclaimedLease},
+		{"PendingRechecks", testPendingRechecks},
+		{"Finality", testFinality},
+		{"ResetForReorg", testResetForReorg},
+		{"EventStream", testEventStream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func hash(n int) string {
+	return fmt.Sprintf("0x%064x", n)
+}
+
+func create(t *testing.T, s store.TransactionStore, nt store.NewTransaction) models.Transaction {
+	t.Helper()
+
+	txn, _, err := s.Create(context.Background(), nt)
+	if err != nil {
+		t.Fatalf("Create(%s): %v", nt.TransactionHash, err)
+	}
+	return txn
+}
+
+func get(t *testing.T, s store.TransactionStore, id string) models.Transaction {
+	t.Helper()
+
+	txn, err := s.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", id, err)
+	}
+	return txn
+}
+
+func events(t *testing.T, s store.TransactionStore, id string) []models.Event {
+	t.Helper()
+
+	events, err := s.Events(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Events(%s): %v", id, err)
+	}
+	return events
+}
+
+func lastEvent(t *testing.T, s store.TransactionStore, id string) models.Event {
+	t.Helper()
+
+	events := events(t, s, id)
+	if len(events) == 0 {
+		t.Fatalf("no events for %s", id)
+	}
+	return events[len(events)-1]
+}
+
+func ids(txns []models.Transaction) []string {
+	out := make([]string, len(txns))
+	for i, txn := range txns {
+		out[i] = txn.ID
+	}
+	return out
+}
+
+// claim moves up to n of the oldest RECEIVED rows to FETCHING, as a worker would
+func claim(t *testing.T, s store.TransactionStore, n int) {
+	t.Helper()
+
+	if _, err := s.ClaimReceived(context.Background(), "worker-a", n, time.Minute); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+}
+
+func str(p *string) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return *p
+}
+
+func num(n int64) *int64 {
+	return &n
+}
+
+func status(p *models.Status) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return string(*p)
+}
+
+func jsonEqual(t *testing.T, got json.RawMessage, want string) {
+	t.Helper()
+
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %q: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid JSON %q: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+func testCreateIsIdempotent(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	nt := store.NewTransaction{TransactionHash: hash(1), ChainID: 1, SourceService: "checkout", ExternalReference: "order-1"}
+
+	first, created, err := s.Create(ctx, nt)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !created {
+		t.Error("first Create reported an existing row")
+	}
+	if first.Status != "RECEIVED" || first.TransactionHash != nt.TransactionHash || first.ChainID != 1 {
+		t.Errorf("created %+v", first)
+	}
+	if str(first.SourceService) != "checkout" || str(first.ExternalReference) != "order-1" {
+		t.Errorf("source_service = %s, external_reference = %s", str(first.SourceService), str(first.ExternalReference))
+	}
+
+	second, created, err := s.Create(ctx, store.NewTransaction{TransactionHash: hash(1), ChainID: 1, SourceService: "other"})
+	if err != nil {
+		t.Fatalf("second Create: %v", err)
+	}
+	if created {
+		t.Error("second Create reported a new row")
+	}
+	if second.ID != first.ID || str(second.SourceService) != "checkout" {
+		t.Errorf("second Create returned %+v, want the original row", second)
+	}
+
+	// The same hash on another chain is a different transaction
+	other, created, err := s.Create(ctx, store.NewTransaction{TransactionHash: hash(1), ChainID: 137})
+	if err != nil {
+		t.Fatalf("Create on chain 137: %v", err)
+	}
+	if !created || other.ID == first.ID {
+		t.Errorf("Create on chain 137 = %s (created %v), want a new row", other.ID, created)
+	}
+	if other.SourceService != nil || other.ExternalReference != nil {
+		t.Errorf("empty source_service/external_reference stored as %s/%s", str(other.SourceService), str(other.ExternalReference))
+	}
+
+	// A resubmission is not a second registration
+	history := events(t, s, first.ID)
+	if len(history) != 1 {
+		t.Fatalf("%d events after a resubmission, want 1", len(history))
+	}
+	if registered := history[0]; registered.PreviousStatus != nil || registered.NewStatus != "RECEIVED" || str(registered.Reason) != "transaction registered" {
+		t.Errorf("first event = %+v", registered)
+	}
+}
+
+func testCreateMetadata(t *testing.T, s store.TransactionStore) {
+	plain := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	jsonEqual(t, plain.Metadata, `{}`)
+
+	tagged := create(t, s, store.NewTransaction{
+		TransactionHash: hash(2),
+		ChainID:         1,
+		Metadata:        json.RawMessage(`{"team": "payments", "tags": ["a", "b"], "n": 3}`),
+	})
+	jsonEqual(t, tagged.Metadata, `{"team": "payments", "tags": ["a", "b"], "n": 3}`)
+	jsonEqual(t, get(t, s, tagged.ID).Metadata, `{"team": "payments", "tags": ["a", "b"], "n": 3}`)
+}
+
+func testCreateConcurrent(t *testing.T, s store.TransactionStore) {
+	const n = 16
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+		seen    = make(map[string]bool)
+	)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			txn, ok, err := s.Create(context.Background(), store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+			if err != nil {
+				t.Errorf("Create: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				created++
+			}
+			seen[txn.ID] = true
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 || len(seen) != 1 {
+		t.Errorf("%d concurrent Creates made %d rows (%d reported created), want 1", n, len(seen), created)
+	}
+}
+
+func testCreateBatch(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	existing := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+
+	results, err := s.CreateBatch(ctx, []store.NewTransaction{
+		{TransactionHash: hash(2), ChainID: 1, SourceService: "batch"},
+		{TransactionHash: hash(1), ChainID: 1},
+		{TransactionHash: hash(2), ChainID: 1},
+		{TransactionHash: hash(3), ChainID: 1, Metadata: json.RawMessage(`{"k": "v"}`)},
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("CreateBatch returned %d results, want 4", len(results))
+	}
+
+	wantCreated := []bool{true, false, false, true}
+	for i, r := range results {
+		if r.Created != wantCreated[i] {
+			t.Errorf("result %d created = %v, want %v", i, r.Created, wantCreated[i])
+		}
+	}
+	if results[1].Transaction.ID != existing.ID {
+		t.Errorf("existing row resolved to %s, want %s", results[1].Transaction.ID, existing.ID)
+	}
+	if results[0].Transaction.ID != results[2].Transaction.ID {
+		t.Error("repeated item resolved to a different row than its first occurrence")
+	}
+	if str(results[0].Transaction.SourceService) != "batch" {
+		t.Errorf("source_service = %s", str(results[0].Transaction.SourceService))
+	}
+	jsonEqual(t, results[3].Transaction.Metadata, `{"k": "v"}`)
+
+	if got := len(events(t, s, results[0].Transaction.ID)); got != 1 {
+		t.Errorf("batch-created row has %d events, want 1", got)
+	}
+
+	if results, err := s.CreateBatch(ctx, nil); err != nil || len(results) != 0 {
+		t.Errorf("empty CreateBatch = %v, %v", results, err)
+	}
+}
+
+func testGetAndFindByHash(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	onPolygon := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 137})
+	onMainnet := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+
+	got := get(t, s, onMainnet.ID)
+	if got.ID != onMainnet.ID || got.ChainID != 1 || !got.CreatedAt.Equal(onMainnet.CreatedAt) {
+		t.Errorf("Get = %+v, want %+v", got, onMainnet)
+	}
+
+	if _, err := s.Get(ctx, missingID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	all, err := s.FindByHash(ctx, hash(1), nil)
+	if err != nil {
+		t.Fatalf("FindByHash: %v", err)
+	}
+	if want := []string{onMainnet.ID, onPolygon.ID}; !reflect.DeepEqual(ids(all), want) {
+		t.Errorf("FindByHash = %v, want %v ordered by chain", ids(all), want)
+	}
+
+	chainID := 137
+	scoped, err := s.FindByHash(ctx, hash(1), &chainID)
+	if err != nil {
+		t.Fatalf("FindByHash on chain: %v", err)
+	}
+	if want := []string{onPolygon.ID}; !reflect.DeepEqual(ids(scoped), want) {
+		t.Errorf("FindByHash on chain 137 = %v, want %v", ids(scoped), want)
+	}
+
+	none, err := s.FindByHash(ctx, hash(2), nil)
+	if err != nil || len(none) != 0 {
+		t.Errorf("FindByHash(unknown) = %v, %v", none, err)
+	}
+}
+
+func testListFilters(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+
+	a := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1, SourceService: "checkout",
+		Metadata: json.RawMessage(`{"team": "payments", "region": {"code": "eu"}, "tags": ["x", "y"]}`)})
+	b := create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 1, ExternalReference: "ref-b",
+		Metadata: json.RawMessage(`{"team": "risk"}`)})
+	c := create(t, s, store.NewTransaction{TransactionHash: hash(3), ChainID: 137, SourceService: "checkout"})
+
+	claim(t, s, 3)
+	if err := s.Transition(ctx, store.Transition{ID: a.ID, From: models.StatusFetching, To: models.StatusPending, ClaimedBy: "worker-a",
+		ChainData: &store.ChainData{FromAddress: "0xaa", ToAddress: "0xbb", Value: "1", BlockNumber: num(100), BlockHash: "0xb100", GasUsed: num(21000)}}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if err := s.Transition(ctx, store.Transition{ID: c.ID, From: models.StatusFetching, To: models.StatusIncluded, ClaimedBy: "worker-a",
+		ChainData: &store.ChainData{FromAddress: "0xcc", ToAddress: "0xbb", Value: "2", BlockNumber: num(200), BlockHash: "0xb200", GasUsed: num(21000)}}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	chain1, chain137 := 1, 137
+	min150, max150 := int64(150), int64(150)
+
+	tests := []struct {
+		name   string
+		filter store.Filter
+		want   []string
+	}{
+		{"none", store.Filter{}, []string{c.ID, b.ID, a.ID}},
+		{"chain", store.Filter{ChainID: &chain1}, []string{b.ID, a.ID}},
+		{"other chain", store.Filter{ChainID: &chain137}, []string{c.ID}},
+		{"status", store.Filter{Status: models.StatusPending}, []string{a.ID}},
+		{"from address", store.Filter{FromAddress: "0xaa"}, []string{a.ID}},
+		{"to address", store.Filter{ToAddress: "0xbb"}, []string{c.ID, a.ID}},
+		{"block min", store.Filter{BlockNumberMin: &min150}, []string{c.ID}},
+		{"block max", store.Filter{BlockNumberMax: &max150}, []string{a.ID}},
+		{"source service", store.Filter{SourceService: "checkout"}, []string{c.ID, a.ID}},
+		{"external reference", store.Filter{ExternalReference: "ref-b"}, []string{b.ID}},
+		{"metadata", store.Filter{Metadata: json.RawMessage(`{"team": "payments"}`)}, []string{a.ID}},
+		{"nested metadata", store.Filter{Metadata: json.RawMessage(`{"region": {"code": "eu"}}`)}, []string{a.ID}},
+		{"metadata array", store.Filter{Metadata: json.RawMessage(`{"tags": ["y"]}`)}, []string{a.ID}},
+		{"empty metadata", store.Filter{Metadata: json.RawMessage(`{}`)}, []string{c.ID, b.ID, a.ID}},
+		{"metadata mismatch", store.Filter{Metadata: json.RawMessage(`{"team": "ops"}`)}, nil},
+		{"combined", store.Filter{ChainID: &chain1, SourceService: "checkout"}, []string{a.ID}},
+	}
+
+	for _, tt := range tests {
+		got, err := s.List(ctx, store.ListQuery{Filter: tt.filter, Limit: 10})
+		if err != nil {
+			t.Errorf("%s: List: %v", tt.name, err)
+			continue
+		}
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(ids(got), tt.want) {
+			t.Errorf("%s: List = %v, want %v", tt.name, ids(got), tt.want)
+		}
+	}
+}
+
+func testListPagination(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+
+	var created []string
+	for i := range 5 {
+		created = append(created, create(t, s, store.NewTransaction{TransactionHash: hash(i), ChainID: 1}).ID)
+	}
+	// Newest first
+	want := []string{created[4], created[3], created[2], created[1], created[0]}
+
+	page, err := s.List(ctx, store.ListQuery{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !reflect.DeepEqual(ids(page), want[1:3]) {
+		t.Errorf("offset page = %v, want %v", ids(page), want[1:3])
+	}
+
+	var walked []string
+	var after *store.Cursor
+	for {
+		q := store.ListQuery{Limit: 2}
+		if after != nil {
+			q.After, q.Offset = after, 3
+		}
+		page, err := s.List(ctx, q)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		walked = append(walked, ids(page)...)
+
+		last := page[len(page)-1]
+		after = &store.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		if len(walked) > len(want) {
+			t.Fatalf("cursor walk did not terminate: %v", walked)
+		}
+	}
+	if after == nil || !reflect.DeepEqual(walked, want) {
+		t.Errorf("cursor walk = %v, want %v (the cursor overrides offset)", walked, want)
+	}
+}
+
+func testCountByStatus(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	a := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 1})
+	claim(t, s, 1)
+	if err := s.Transition(ctx, store.Transition{ID: a.ID, From: models.StatusFetching, To: models.StatusPending, ClaimedBy: "worker-a"}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	stats, err := s.CountByStatus(ctx)
+	if err != nil {
+		t.Fatalf("CountByStatus: %v", err)
+	}
+	if want := map[string]int{"RECEIVED": 1, "PENDING": 1}; !reflect.DeepEqual(stats, want) {
+		t.Errorf("CountByStatus = %v, want %v", stats, want)
+	}
+}
+
+func testTransition(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	claim(t, s, 1)
+
+	// A pending transaction has no block or gas yet, which must stay NULL
+	pending := &store.ChainData{FromAddress: "0xaa", ToAddress: "0xbb", Value: "1"}
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusPending, Provider: "infura", ClaimedBy: "worker-a", ChainData: pending}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if got := get(t, s, txn.ID); got.Status != "PENDING" || got.UpdatedAt.Before(txn.UpdatedAt) || got.ErrorReason != nil {
+		t.Errorf("after Transition: status %s, updated_at %v, error_reason %q", got.Status, got.UpdatedAt, str(got.ErrorReason))
+	}
+	if got := get(t, s, txn.ID); str(got.FromAddress) != "0xaa" || got.BlockNumber != nil || got.BlockHash != nil || got.GasUsed != nil {
+		t.Errorf("pending chain data stored as block %v, hash %v, gas %v", got.BlockNumber, got.BlockHash, got.GasUsed)
+	}
+
+	event := lastEvent(t, s, txn.ID)
+	if status(event.PreviousStatus) != "FETCHING" || event.NewStatus != "PENDING" || str(event.Provider) != "infura" {
+		t.Errorf("event = %s → %s via %s", status(event.PreviousStatus), event.NewStatus, str(event.Provider))
+	}
+	if want := "Status changed by worker: FETCHING → PENDING"; str(event.Reason) != want {
+		t.Errorf("default reason = %q, want %q", str(event.Reason), want)
+	}
+
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusPending, To: models.StatusFailed, Reason: "execution reverted"}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	got := get(t, s, txn.ID)
+	if got.Status != "FAILED" || str(got.ErrorReason) != "execution reverted" {
+		t.Errorf("after FAILED: status %s, error_reason %s", got.Status, str(got.ErrorReason))
+	}
+
+	event = lastEvent(t, s, txn.ID)
+	if status(event.PreviousStatus) != "PENDING" || str(event.Reason) != "execution reverted" || event.Provider != nil {
+		t.Errorf("event = %+v", event)
+	}
+
+	history := events(t, s, txn.ID)
+	for i := 1; i < len(history); i++ {
+		if history[i].Seq <= history[i-1].Seq {
+			t.Errorf("events out of order: seq %d after %d", history[i].Seq, history[i-1].Seq)
+		}
+	}
+	if len(history) != 4 {
+		t.Errorf("%d events, want 4", len(history))
+	}
+}
+
+func testTransitionErrors(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+
+	if err := s.Transition(ctx, store.Transition{ID: missingID, From: models.StatusFetching, To: models.StatusPending, ClaimedBy: "worker-a"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Transition(missing) error = %v, want ErrNotFound", err)
+	}
+
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusReceived, To: "NOT_A_STATUS"}); !errors.Is(err, models.ErrIllegalTransition) {
+		t.Errorf("Transition to an unknown status error = %v, want ErrIllegalTransition", err)
+	}
+
+	err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusReceived, To: models.StatusConfirmed})
+	var illegal *models.IllegalTransitionError
+	if !errors.As(err, &illegal) || illegal.From != models.StatusReceived || illegal.To != models.StatusConfirmed {
+		t.Errorf("Transition RECEIVED → CONFIRMED error = %v, want IllegalTransitionError", err)
+	}
+
+	// Leaving an in-flight status has to name the worker holding the lease
+	claim(t, s, 1)
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusPending}); !errors.Is(err, store.ErrClaimRequired) {
+		t.Errorf("Transition without ClaimedBy error = %v, want ErrClaimRequired", err)
+	}
+	if err := s.ScheduleRetry(ctx, store.Retry{ID: txn.ID, From: models.StatusFetching}); !errors.Is(err, store.ErrClaimRequired) {
+		t.Errorf("ScheduleRetry without ClaimedBy error = %v, want ErrClaimRequired", err)
+	}
+	if err := s.ScheduleRetry(ctx, store.Retry{ID: txn.ID, From: models.StatusFetching, ClaimedBy: "worker-a", Reason: "retrying"}); err != nil {
+		t.Fatalf("ScheduleRetry: %v", err)
+	}
+
+	// A legal transition from a status the row has already left lost a race
+	err = s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusPending, ClaimedBy: "worker-a"})
+	var conflict *store.StatusConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, store.ErrStatusConflict) {
+		t.Fatalf("Transition from a stale status error = %v, want StatusConflictError", err)
+	}
+	if conflict.ID != txn.ID || conflict.Expected != models.StatusFetching || conflict.Actual != models.StatusReceived {
+		t.Errorf("conflict = %+v", conflict)
+	}
+
+	if got := get(t, s, txn.ID); got.Status != "RECEIVED" {
+		t.Errorf("failed Transition left status %s", got.Status)
+	}
+	if got := len(events(t, s, txn.ID)); got != 3 {
+		t.Errorf("failed Transition left %d events, want 3", got)
+	}
+}
+
+func testClaimAndRetry(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	first := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	second := create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 137})
+
+	claimed, err := s.ClaimReceived(ctx, "worker-a", 1, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	want := []store.Claim{{ID: first.ID, Hash: first.TransactionHash, ChainID: 1, Attempt: 1}}
+	if !reflect.DeepEqual(claimed, want) {
+		t.Fatalf("ClaimReceived = %+v, want oldest first %+v", claimed, want)
+	}
+
+	got := get(t, s, first.ID)
+	if got.Status != "FETCHING" || got.AttemptCount != 1 {
+		t.Errorf("claimed row: status %s, attempt_count %d", got.Status, got.AttemptCount)
+	}
+	if event := lastEvent(t, s, first.ID); event.NewStatus != "FETCHING" || str(event.Reason) != "claimed by worker worker-a" {
+		t.Errorf("claim event = %s: %s", event.NewStatus, str(event.Reason))
+	}
+
+	// A claimed row is not handed out again
+	claimed, err = s.ClaimReceived(ctx, "worker-b", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != second.ID {
+		t.Fatalf("second ClaimReceived = %+v, want only %s", claimed, second.ID)
+	}
+
+	err = s.ScheduleRetry(ctx, store.Retry{ID: first.ID, From: models.StatusFetching, ClaimedBy: "worker-a", LastError: "timeout", Reason: "retrying", Delay: time.Hour, Provider: "infura"})
+	if err != nil {
+		t.Fatalf("ScheduleRetry: %v", err)
+	}
+	got = get(t, s, first.ID)
+	if got.Status != "RECEIVED" || str(got.LastError) != "timeout" || got.NextAttemptAt == nil {
+		t.Errorf("after ScheduleRetry: status %s, last_error %s, next_attempt_at %v", got.Status, str(got.LastError), got.NextAttemptAt)
+	}
+	if event := lastEvent(t, s, first.ID); event.NewStatus != "RECEIVED" || str(event.Reason) != "retrying" || str(event.Provider) != "infura" {
+		t.Errorf("retry event = %+v", event)
+	}
+
+	// Still backing off
+	if claimed, err := s.ClaimReceived(ctx, "worker-a", 10, time.Minute); err != nil || len(claimed) != 0 {
+		t.Errorf("ClaimReceived during backoff = %+v, %v", claimed, err)
+	}
+
+	if err := s.ScheduleRetry(ctx, store.Retry{ID: second.ID, From: models.StatusFetching, ClaimedBy: "worker-b", LastError: "timeout", Reason: "retrying"}); err != nil {
+		t.Fatalf("ScheduleRetry: %v", err)
+	}
+	claimed, err = s.ClaimReceived(ctx, "worker-a", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != second.ID || claimed[0].Attempt != 2 {
+		t.Errorf("ClaimReceived after due retry = %+v, want %s on attempt 2", claimed, second.ID)
+	}
+
+	// A refunded attempt doesn't count towards the row's attempts
+	err = s.ScheduleRetry(ctx, store.Retry{ID: second.ID, From: models.StatusFetching, ClaimedBy: "worker-a", LastError: "budget", Reason: "deferred", RefundAttempt: true})
+	if err != nil {
+		t.Fatalf("ScheduleRetry: %v", err)
+	}
+	if got := get(t, s, second.ID); got.Status != "RECEIVED" || got.AttemptCount != 1 {
+		t.Errorf("after refunded retry: status %s, attempt_count %d; want RECEIVED on 1", got.Status, got.AttemptCount)
+	}
+
+	if err := s.ScheduleRetry(ctx, store.Retry{ID: missingID, From: models.StatusFetching, ClaimedBy: "worker-a"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ScheduleRetry(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testDeadLetter(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	if _, err := s.ClaimReceived(ctx, "worker-a", 1, time.Minute); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+
+	if err := s.DeadLetter(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, Reason: "giving up", ClaimedBy: "worker-a"}, "boom"); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+
+	got := get(t, s, txn.ID)
+	if got.Status != "ERROR" || str(got.ErrorReason) != "giving up" || str(got.LastError) != "boom" || got.NextAttemptAt != nil {
+		t.Errorf("after DeadLetter: %+v", got)
+	}
+	if event := lastEvent(t, s, txn.ID); status(event.PreviousStatus) != "FETCHING" || event.NewStatus != "ERROR" {
+		t.Errorf("dead-letter event = %s → %s", status(event.PreviousStatus), event.NewStatus)
+	}
+
+	// ERROR rows are not claimed
+	if claimed, err := s.ClaimReceived(ctx, "worker-a", 10, time.Minute); err != nil || len(claimed) != 0 {
+		t.Errorf("ClaimReceived = %+v, %v", claimed, err)
+	}
+
+	// A second worker dead-lettering the same claim lost the race
+	if err := s.DeadLetter(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, ClaimedBy: "worker-a"}, "boom"); !errors.Is(err, store.ErrStatusConflict) {
+		t.Errorf("repeated DeadLetter error = %v, want ErrStatusConflict", err)
+	}
+
+	if err := s.DeadLetter(ctx, store.Transition{ID: missingID, From: models.StatusFetching, ClaimedBy: "worker-a"}, ""); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeadLetter(missing) error = %v, want ErrNotFound", err)
+	}
+
+	// Without a reason error_reason stays NULL rather than empty
+	bare := create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 1})
+	claim(t, s, 1)
+	if err := s.DeadLetter(ctx, store.Transition{ID: bare.ID, From: models.StatusFetching, ClaimedBy: "worker-a"}, "boom"); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+	if got := get(t, s, bare.ID); got.Status != "ERROR" || got.ErrorReason != nil {
+		t.Errorf("after DeadLetter without a reason: status %s, error_reason %q", got.Status, str(got.ErrorReason))
+	}
+}
+
+func testRequeue(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	claim(t, s, 1)
+	if err := s.DeadLetter(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, Reason: "giving up", ClaimedBy: "worker-a"}, "boom"); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+
+	if err := s.Requeue(ctx, store.Transition{ID: txn.ID, From: models.StatusError}); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	got := get(t, s, txn.ID)
+	if got.Status != "RECEIVED" || got.AttemptCount != 0 || got.NextAttemptAt != nil || got.ErrorReason != nil || str(got.LastError) != "boom" {
+		t.Errorf("after Requeue: %+v", got)
+	}
+	if event := lastEvent(t, s, txn.ID); status(event.PreviousStatus) != "ERROR" || str(event.Reason) != "requeued by operator from ERROR" {
+		t.Errorf("requeue event = %s: %s", status(event.PreviousStatus), str(event.Reason))
+	}
+
+	// Claimable at once, starting over at attempt 1
+	claimed, err := s.ClaimReceived(ctx, "worker-a", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != txn.ID || claimed[0].Attempt != 1 {
+		t.Errorf("ClaimReceived after Requeue = %+v", claimed)
+	}
+
+	if err := s.Requeue(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching}); !errors.Is(err, models.ErrIllegalTransition) {
+		t.Errorf("Requeue from FETCHING error = %v, want ErrIllegalTransition", err)
+	}
+	if err := s.Requeue(ctx, store.Transition{ID: txn.ID, From: models.StatusError}); !errors.Is(err, store.ErrStatusConflict) {
+		t.Errorf("Requeue of a row no longer in ERROR error = %v, want ErrStatusConflict", err)
+	}
+}
+
+func testLeases(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	kept := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	if _, err := s.ClaimReceived(ctx, "live", 1, time.Millisecond); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	lost := create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 1})
+	if _, err := s.ClaimReceived(ctx, "dead", 1, time.Millisecond); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+
+	if err := s.RenewLeases(ctx, "live", time.Hour); err != nil {
+		t.Fatalf("RenewLeases: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	reaped, err := s.ReapExpiredLeases(ctx, 2)
+	if err != nil {
+		t.Fatalf("ReapExpiredLeases: %v", err)
+	}
+	want := []store.ReapedLease{{ID: lost.ID, Hash: lost.TransactionHash, PreviousStatus: "FETCHING", ClaimedBy: "dead", NewStatus: "RECEIVED", Attempt: 1}}
+	if !reflect.DeepEqual(reaped, want) {
+		t.Fatalf("ReapExpiredLeases = %+v, want %+v", reaped, want)
+	}
+
+	if got := get(t, s, lost.ID); got.Status != "RECEIVED" {
+		t.Errorf("reaped row status = %s", got.Status)
+	}
+	if got := get(t, s, kept.ID); got.Status != "FETCHING" {
+		t.Errorf("renewed row status = %s", got.Status)
+	}
+	event := lastEvent(t, s, lost.ID)
+	if want := "lease expired in FETCHING (worker dead); returned to RECEIVED"; str(event.Reason) != want {
+		t.Errorf("reap reason = %q, want %q", str(event.Reason), want)
+	}
+
+	// The reaped row is claimable again, on its second attempt
+	claimed, err := s.ClaimReceived(ctx, "dead", 10, time.Millisecond)
+	if err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != lost.ID || claimed[0].Attempt != 2 {
+		t.Fatalf("ClaimReceived = %+v", claimed)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// A row that outlives its last attempt's lease is dead-lettered, not reclaimed forever
+	reaped, err = s.ReapExpiredLeases(ctx, 2)
+	if err != nil {
+		t.Fatalf("ReapExpiredLeases: %v", err)
+	}
+	if len(reaped) != 1 || reaped[0].NewStatus != "ERROR" || reaped[0].Attempt != 2 {
+		t.Fatalf("ReapExpiredLeases on the last attempt = %+v", reaped)
+	}
+
+	reason := "lease expired in FETCHING (worker dead) after 2 attempts; dead-lettered"
+	got := get(t, s, lost.ID)
+	if got.Status != "ERROR" || str(got.ErrorReason) != reason || str(got.LastError) != reason {
+		t.Errorf("dead-lettered row: status %s, error_reason %s, last_error %s", got.Status, str(got.ErrorReason), str(got.LastError))
+	}
+	if event := lastEvent(t, s, lost.ID); status(event.PreviousStatus) != "FETCHING" || event.NewStatus != "ERROR" || str(event.Reason) != reason {
+		t.Errorf("dead-letter event = %s → %s: %s", status(event.PreviousStatus), event.NewStatus, str(event.Reason))
+	}
+	if claimed, err := s.ClaimReceived(ctx, "live", 10, time.Minute); err != nil || len(claimed) != 0 {
+		t.Errorf("ClaimReceived after dead-letter = %+v, %v", claimed, err)
+	}
+}
+
+// testReclaimedLease covers a worker that stalls past its lease: once the row
+// has been reaped and claimed by another worker, the stalled worker's late
+// writes must all fail and leave the new claim alone
+func testReclaimedLease(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	if _, err := s.ClaimReceived(ctx, "worker-a", 1, time.Millisecond); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if reaped, err := s.ReapExpiredLeases(ctx, 3); err != nil || len(reaped) != 1 {
+		t.Fatalf("ReapExpiredLeases = %+v, %v", reaped, err)
+	}
+	claimed, err := s.ClaimReceived(ctx, "worker-b", 1, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != txn.ID {
+		t.Fatalf("ClaimReceived = %+v, %v", claimed, err)
+	}
+	before := len(events(t, s, txn.ID))
+
+	stale := &store.ChainData{FromAddress: "0xaa", ToAddress: "0xbb", Value: "1", BlockNumber: num(100), BlockHash: "0xstale", GasUsed: num(21000)}
+	err = s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusIncluded, ClaimedBy: "worker-a", ChainData: stale})
+	var conflict *store.StatusConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("late Transition error = %v, want StatusConflictError", err)
+	}
+	if conflict.Actual != models.StatusFetching || conflict.ExpectedClaim != "worker-a" || conflict.ActualClaim != "worker-b" {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if err := s.ScheduleRetry(ctx, store.Retry{ID: txn.ID, From: models.StatusFetching, ClaimedBy: "worker-a"}); !errors.Is(err, store.ErrStatusConflict) {
+		t.Errorf("late ScheduleRetry error = %v, want ErrStatusConflict", err)
+	}
+	if err := s.DeadLetter(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, ClaimedBy: "worker-a"}, "boom"); !errors.Is(err, store.ErrStatusConflict) {
+		t.Errorf("late DeadLetter error = %v, want ErrStatusConflict", err)
+	}
+
+	got := get(t, s, txn.ID)
+	if got.Status != "FETCHING" || got.BlockHash != nil || got.FromAddress != nil || got.LastError != nil {
+		t.Errorf("late writes changed the row: %+v", got)
+	}
+	if n := len(events(t, s, txn.ID)); n != before {
+		t.Errorf("late writes recorded %d events", n-before)
+	}
+
+	// The worker now holding the lease still finishes the row
+	fresh := &store.ChainData{FromAddress: "0xaa", ToAddress: "0xbb", Value: "1", BlockNumber: num(101), BlockHash: "0xfresh", GasUsed: num(21000)}
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusIncluded, ClaimedBy: "worker-b", ChainData: fresh}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if got := get(t, s, txn.ID); got.Status != "INCLUDED" || str(got.BlockHash) != "0xfresh" {
+		t.Errorf("after Transition: status %s, block_hash %s", got.Status, str(got.BlockHash))
+	}
+}
+
+func testPendingRechecks(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 1})
+	claim(t, s, 1)
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusPending, ClaimedBy: "worker-a"}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	due, err := s.ClaimDuePending(ctx, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("ClaimDuePending: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != txn.ID || due[0].CheckCount != 0 || due[0].PendingSince.IsZero() {
+		t.Fatalf("ClaimDuePending = %+v", due)
+	}
+	pendingSince := due[0].PendingSince
+
+	// Held for the hold period
+	if due, err := s.ClaimDuePending(ctx, 10, time.Hour); err != nil || len(due) != 0 {
+		t.Errorf("ClaimDuePending while held = %+v, %v", due, err)
+	}
+
+	if err := s.ScheduleRecheck(ctx, txn.ID, 0); err != nil {
+		t.Fatalf("ScheduleRecheck: %v", err)
+	}
+	due, err = s.ClaimDuePending(ctx, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("ClaimDuePending: %v", err)
+	}
+	if len(due) != 1 || due[0].CheckCount != 1 || !due[0].PendingSince.Equal(pendingSince) {
+		t.Errorf("ClaimDuePending after recheck = %+v, want check 1 pending since %v", due, pendingSince)
+	}
+}
+
+func testFinality(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	high := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	low := create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 1})
+	other := create(t, s, store.NewTransaction{TransactionHash: hash(3), ChainID: 137})
+	claim(t, s, 3)
+
+	confirmations := int64(3)
+	for _, c := range []struct {
+		txn   models.Transaction
+		block int64
+	}{{high, 200}, {low, 100}, {other, 150}} {
+		data := &store.ChainData{FromAddress: "0xaa", ToAddress: "0xbb", Value: "10", BlockNumber: num(c.block), BlockHash: fmt.Sprintf("0xb%d", c.block), GasUsed: num(21000), Confirmations: &confirmations}
+		if err := s.Transition(ctx, store.Transition{ID: c.txn.ID, From: models.StatusFetching, To: models.StatusIncluded, ClaimedBy: "worker-a", ChainData: data}); err != nil {
+			t.Fatalf("Transition: %v", err)
+		}
+	}
+
+	got := get(t, s, low.ID)
+	if str(got.FromAddress) != "0xaa" || str(got.Value) != "10" || got.BlockNumber == nil || *got.BlockNumber != 100 ||
+		str(got.BlockHash) != "0xb100" || got.GasUsed == nil || *got.GasUsed != 21000 || got.Confirmations == nil || *got.Confirmations != 3 {
+		t.Errorf("Transition stored chain data %+v", got)
+	}
+
+	included, err := s.ListIncluded(ctx, 2)
+	if err != nil {
+		t.Fatalf("ListIncluded: %v", err)
+	}
+	want := []store.MinedTransaction{
+		{ID: low.ID, Hash: low.TransactionHash, ChainID: 1, Status: models.StatusIncluded, BlockNumber: 100, BlockHash: "0xb100"},
+		{ID: other.ID, Hash: other.TransactionHash, ChainID: 137, Status: models.StatusIncluded, BlockNumber: 150, BlockHash: "0xb150"},
+	}
+	if !reflect.DeepEqual(included, want) {
+		t.Errorf("ListIncluded = %+v, want %+v", included, want)
+	}
+
+	if err := s.SetConfirmations(ctx, low.ID, 100, 12); err != nil {
+		t.Fatalf("SetConfirmations: %v", err)
+	}
+	if got := get(t, s, low.ID); got.Confirmations == nil || *got.Confirmations != 12 {
+		t.Errorf("confirmations = %v", got.Confirmations)
+	}
+
+	// A count read before a reorg moved the row to another block is dropped
+	if err := s.SetConfirmations(ctx, low.ID, 99, 40); err != nil {
+		t.Fatalf("SetConfirmations: %v", err)
+	}
+	if got := get(t, s, low.ID); got.Confirmations == nil || *got.Confirmations != 12 {
+		t.Errorf("confirmations for a stale block = %v, want 12 kept", got.Confirmations)
+	}
+
+	// So is one for a row a reorg has since sent back to RECEIVED
+	if _, err := s.ResetForReorg(ctx, store.Transition{ID: other.ID, From: models.StatusIncluded, To: models.StatusReceived}, "0xb150"); err != nil {
+		t.Fatalf("ResetForReorg: %v", err)
+	}
+	if err := s.SetConfirmations(ctx, other.ID, 150, 9); err != nil {
+		t.Fatalf("SetConfirmations: %v", err)
+	}
+	if got := get(t, s, other.ID); got.Confirmations != nil && *got.Confirmations == 9 {
+		t.Errorf("confirmations set on a %s row", got.Status)
+	}
+
+	// Rewriting the same count leaves updated_at alone
+	before := get(t, s, low.ID).UpdatedAt
+	if err := s.SetConfirmations(ctx, low.ID, 100, 12); err != nil {
+		t.Fatalf("SetConfirmations: %v", err)
+	}
+	if got := get(t, s, low.ID); !got.UpdatedAt.Equal(before) {
+		t.Errorf("updated_at moved from %v to %v for an unchanged count", before, got.UpdatedAt)
+	}
+	if err := s.Transition(ctx, store.Transition{ID: low.ID, From: models.StatusIncluded, To: models.StatusConfirmed}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	mined, err := s.ListMinedSince(ctx, 1, 100)
+	if err != nil {
+		t.Fatalf("ListMinedSince: %v", err)
+	}
+	if len(mined) != 2 || mined[0].ID != low.ID || mined[1].ID != high.ID {
+		t.Errorf("ListMinedSince(1, 100) = %+v", mined)
+	}
+
+	mined, err = s.ListMinedSince(ctx, 1, 101)
+	if err != nil {
+		t.Fatalf("ListMinedSince: %v", err)
+	}
+	if len(mined) != 1 || mined[0].ID != high.ID {
+		t.Errorf("ListMinedSince(1, 101) = %+v", mined)
+	}
+}
+
+func testResetForReorg(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+	txn := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1})
+	if _, err := s.ClaimReceived(ctx, "worker-a", 1, time.Minute); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	if err := s.Transition(ctx, store.Transition{ID: txn.ID, From: models.StatusFetching, To: models.StatusIncluded, ClaimedBy: "worker-a",
+		ChainData: &store.ChainData{FromAddress: "0xaa", ToAddress: "0xbb", Value: "1", BlockNumber: num(100), BlockHash: "0xorphan", GasUsed: num(21000)}}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	reset, err := s.ResetForReorg(ctx, store.Transition{ID: txn.ID, From: models.StatusIncluded, Reason: "reorg"}, "0xcanonical")
+	if err != nil || reset {
+		t.Errorf("ResetForReorg with a stale hash = %v, %v; want a no-op", reset, err)
+	}
+
+	// The row moved on since the reorg scan read it
+	if _, err := s.ResetForReorg(ctx, store.Transition{ID: txn.ID, From: models.StatusConfirmed, Reason: "reorg"}, "0xorphan"); !errors.Is(err, store.ErrStatusConflict) {
+		t.Errorf("ResetForReorg from a stale status error = %v, want ErrStatusConflict", err)
+	}
+
+	reset, err = s.ResetForReorg(ctx, store.Transition{ID: txn.ID, From: models.StatusIncluded, Reason: "reorg", Provider: "infura"}, "0xorphan")
+	if err != nil || !reset {
+		t.Fatalf("ResetForReorg = %v, %v", reset, err)
+	}
+
+	got := get(t, s, txn.ID)
+	if got.Status != "RECEIVED" || got.BlockNumber != nil || got.BlockHash != nil || got.GasUsed != nil || got.AttemptCount != 0 {
+		t.Errorf("after reorg reset: %+v", got)
+	}
+	if str(got.FromAddress) != "0xaa" {
+		t.Errorf("reorg reset cleared from_address")
+	}
+	if event := lastEvent(t, s, txn.ID); status(event.PreviousStatus) != "INCLUDED" || str(event.Reason) != "reorg" || str(event.Provider) != "infura" {
+		t.Errorf("reorg event = %+v", event)
+	}
+
+	if reset, err := s.ResetForReorg(ctx, store.Transition{ID: missingID, From: models.StatusIncluded}, "0xorphan"); err != nil || reset {
+		t.Errorf("ResetForReorg(missing) = %v, %v", reset, err)
+	}
+}
+
+func testEventStream(t *testing.T, s store.TransactionStore) {
+	ctx := context.Background()
+
+	start, err := s.LatestEventSeq(ctx)
+	if err != nil {
+		t.Fatalf("LatestEventSeq: %v", err)
+	}
+
+	a := create(t, s, store.NewTransaction{TransactionHash: hash(1), ChainID: 1, SourceService: "checkout"})
+	b := create(t, s, store.NewTransaction{TransactionHash: hash(2), ChainID: 137})
+	if _, err := s.ClaimReceived(ctx, "worker-a", 10, time.Minute); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+
+	latest, err := s.LatestEventSeq(ctx)
+	if err != nil {
+		t.Fatalf("LatestEventSeq: %v", err)
+	}
+
+	seqs, err := s.EventSeqs(ctx, start, 100)
+	if err != nil {
+		t.Fatalf("EventSeqs: %v", err)
+	}
+	if len(seqs) != 4 || seqs[len(seqs)-1] != latest {
+		t.Fatalf("EventSeqs = %v, want 4 ending at %d", seqs, latest)
+	}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] <= seqs[i-1] {
+			t.Errorf("EventSeqs not ascending: %v", seqs)
+		}
+	}
+
+	if limited, err := s.EventSeqs(ctx, start, 2); err != nil || !reflect.DeepEqual(limited, seqs[:2]) {
+		t.Errorf("EventSeqs limit 2 = %v, %v; want %v", limited, err, seqs[:2])
+	}
+
+	all, err := s.ListEvents(ctx, store.EventQuery{AfterSeq: start, UpToSeq: latest})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("ListEvents = %d events, want 4", len(all))
+	}
+	for _, event := range all {
+		txn := a
+		if event.TransactionID == b.ID {
+			txn = b
+		}
+		if event.TransactionHash != txn.TransactionHash || event.ChainID != txn.ChainID {
+			t.Errorf("event %d for %s/%d, want %s/%d", event.Seq, event.TransactionHash, event.ChainID, txn.TransactionHash, txn.ChainID)
+		}
+	}
+
+	// Status matches the event's new status, the other filters the transaction
+	claims, err := s.ListEvents(ctx, store.EventQuery{AfterSeq: start, UpToSeq: latest, Filter: store.Filter{Status: "FETCHING", SourceService: "checkout"}})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(claims) != 1 || claims[0].TransactionID != a.ID || claims[0].NewStatus != "FETCHING" {
+		t.Errorf("filtered ListEvents = %+v", claims)
+	}
+
+	// The range is half-open: (AfterSeq, UpToSeq]
+	window, err := s.ListEvents(ctx, store.EventQuery{AfterSeq: seqs[0], UpToSeq: seqs[1]})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(window) != 1 || window[0].Seq != seqs[1] {
+		t.Errorf("ListEvents(%d, %d] = %+v", seqs[0], seqs[1], window)
+	}
+}
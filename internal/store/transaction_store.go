@@ -0,0 +1,268 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+// ErrNotFound is returned when a transaction does not exist
+var ErrNotFound = errors.New("transaction not found")
+
+// ErrStatusConflict is matched by every StatusConflictError
+var ErrStatusConflict = errors.New("transaction status changed concurrently")
+
+// ErrClaimRequired is returned for a change out of an in-flight status that
+// doesn't name the worker holding the lease
+var ErrClaimRequired = errors.New("changing an in-flight transaction requires the claiming worker")
+
+// StatusConflictError is returned when a status change loses a race: the row
+// was no longer in the status the caller expected, or is now leased to
+// another worker
+type StatusConflictError struct {
+	ID       string
+	Expected models.Status
+	Actual   models.Status
+
+	// ExpectedClaim and ActualClaim are the worker the caller acted for and
+	// the one holding the row, for changes out of an in-flight status
+	ExpectedClaim string
+	ActualClaim   string
+}
+
+func (e *StatusConflictError) Error() string {
+	if e.Actual == e.Expected && e.ExpectedClaim != "" && e.ActualClaim != e.ExpectedClaim {
+		return fmt.Sprintf("transaction %s is claimed by %q, expected %q", e.ID, e.ActualClaim, e.ExpectedClaim)
+	}
+	return fmt.Sprintf("transaction %s is %s, expected %s", e.ID, e.Actual, e.Expected)
+}
+
+func (e *StatusConflictError) Is(target error) bool {
+	return target == ErrStatusConflict
+}
+
+// TransactionStore is the single place transactions and their events are
+// read and written. The API and the worker both go through it.
+type TransactionStore interface {
+	// Create registers a transaction, or returns the existing row for the
+	// same (hash, chain). created reports which happened.
+	Create(ctx context.Context, t NewTransaction) (tx models.Transaction, created bool, err error)
+	// CreateBatch registers many transactions at once; results follow input order
+	CreateBatch(ctx context.Context, ts []NewTransaction) ([]CreateResult, error)
+
+	Get(ctx context.Context, id string) (models.Transaction, error)
+	// FindByHash returns every transaction with the hash, optionally on one chain, by chain ID
+	FindByHash(ctx context.Context, hash string, chainID *int) ([]models.Transaction, error)
+	// List returns transactions newest first, after the cursor or offset
+	List(ctx context.Context, q ListQuery) ([]models.Transaction, error)
+	CountByStatus(ctx context.Context) (map[string]int, error)
+
+	// Events returns one transaction's events, oldest first
+	Events(ctx context.Context, txID string) ([]models.Event, error)
+	// LatestEventSeq returns the highest event seq, 0 when there are none
+	LatestEventSeq(ctx context.Context) (int64, error)
+	// EventSeqs returns up to limit seqs after the given one, ascending
+	EventSeqs(ctx context.Context, after int64, limit int) ([]int64, error)
+	// ListEvents returns events in (AfterSeq, UpToSeq] matching the filter
+	ListEvents(ctx context.Context, q EventQuery) ([]models.Event, error)
+
+	// ClaimReceived leases up to limit due RECEIVED rows to workerID and moves them to FETCHING
+	ClaimReceived(ctx context.Context, workerID string, limit int, lease time.Duration) ([]Claim, error)
+	// RenewLeases extends every in-flight lease held by workerID
+	RenewLeases(ctx context.Context, workerID string, lease time.Duration) error
+	// ReapExpiredLeases returns in-flight rows with expired leases to RECEIVED,
+	// or dead-letters them in ERROR once they have been claimed maxAttempts times
+	ReapExpiredLeases(ctx context.Context, maxAttempts int) ([]ReapedLease, error)
+
+	// ClaimDuePending hides up to limit due PENDING rows from other workers for hold
+	ClaimDuePending(ctx context.Context, limit int, hold time.Duration) ([]PendingCheck, error)
+	// ScheduleRecheck sets a PENDING row's next check and counts the check
+	ScheduleRecheck(ctx context.Context, id string, delay time.Duration) error
+
+	// ListIncluded returns up to limit INCLUDED rows, lowest block first
+	ListIncluded(ctx context.Context, limit int) ([]MinedTransaction, error)
+	// SetConfirmations records confirmations for a mined row. It does nothing
+	// if the row has left INCLUDED/CONFIRMED or been reorged off blockNumber
+	// since the caller read it.
+	SetConfirmations(ctx context.Context, id string, blockNumber, confirmations int64) error
+	// ListMinedSince returns rows with a stored block at or above fromBlock
+	ListMinedSince(ctx context.Context, chainID int, fromBlock int64) ([]MinedTransaction, error)
+
+	// The status changes below apply only if the row is still in the expected
+	// From status and, when From is in flight, still claimed by ClaimedBy.
+	// They return a models.IllegalTransitionError for a change the state
+	// machine forbids, and a StatusConflictError when the row has moved on
+	// since the caller read it.
+
+	// Transition changes status, storing any ChainData with it, releasing
+	// any claim, and records the event
+	Transition(ctx context.Context, t Transition) error
+	// ScheduleRetry returns a row to RECEIVED, hidden from claims for Delay
+	ScheduleRetry(ctx context.Context, r Retry) error
+	// DeadLetter moves a row to ERROR until an operator requeues it
+	DeadLetter(ctx context.Context, t Transition, lastError string) error
+	// ResetForReorg returns a row mined in an orphaned block to RECEIVED.
+	// It is a no-op returning false if the row's block hash has changed since.
+	ResetForReorg(ctx context.Context, t Transition, orphanedBlockHash string) (bool, error)
+	// Requeue gives a dead-lettered or dropped row a fresh set of attempts in RECEIVED
+	Requeue(ctx context.Context, t Transition) error
+}
+
+// validateRequeue only lets operators requeue parked rows; the other ways back
+// to RECEIVED belong to the worker
+func validateRequeue(from models.Status) error {
+	if !from.Requeueable() {
+		return &models.IllegalTransitionError{From: from, To: models.StatusReceived}
+	}
+	return nil
+}
+
+// inFlightStatuses are the states a worker holds a lease for. A row left in
+// one of them with an expired lease belongs to a worker that died mid-flight.
+var inFlightStatuses = []models.Status{models.StatusFetching}
+
+// requireClaim rejects a change out of an in-flight status that doesn't say
+// which worker it is made for. Without it, a worker whose lease was reaped
+// could overwrite the row after another worker claimed it.
+func requireClaim(from models.Status, claimedBy string) error {
+	if claimedBy == "" && slices.Contains(inFlightStatuses, from) {
+		return fmt.Errorf("%w: leaving %s", ErrClaimRequired, from)
+	}
+	return nil
+}
+
+// NewTransaction is a submission. Hash must already be normalized.
+type NewTransaction struct {
+	TransactionHash   string
+	ChainID           int
+	SourceService     string
+	Metadata          json.RawMessage // must be a JSON object; nil means {}
+	ExternalReference string
+}
+
+// CreateResult is the outcome of one batch item
+type CreateResult struct {
+	Transaction models.Transaction
+	Created     bool
+}
+
+// Filter narrows List and ListEvents. Zero fields match everything. For
+// ListEvents, Status matches the event's new status rather than the row's.
+type Filter struct {
+	FromAddress       string
+	ToAddress         string
+	ChainID           *int
+	Status            models.Status
+	BlockNumberMin    *int64
+	BlockNumberMax    *int64
+	SourceService     string
+	ExternalReference string
+	Metadata          json.RawMessage // rows whose metadata contains this object
+}
+
+// Cursor is a position in (created_at DESC, id DESC) order
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ListQuery selects a page of transactions. After takes precedence over Offset.
+type ListQuery struct {
+	Filter Filter
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// EventQuery selects events by seq range
+type EventQuery struct {
+	AfterSeq int64
+	UpToSeq  int64
+	Filter   Filter
+}
+
+// Claim is a row leased to a worker
+type Claim struct {
+	ID      string
+	Hash    string
+	ChainID int
+	Attempt int // 1 on the first try
+}
+
+// ReapedLease is a row recovered from a worker that stopped renewing its lease
+type ReapedLease struct {
+	ID             string
+	Hash           string
+	PreviousStatus models.Status
+	ClaimedBy      string
+	NewStatus      models.Status // RECEIVED, or ERROR when its attempts are used up
+	Attempt        int
+}
+
+// PendingCheck is a PENDING row due for a re-check
+type PendingCheck struct {
+	ID           string
+	Hash         string
+	ChainID      int
+	PendingSince time.Time
+	CheckCount   int
+}
+
+// MinedTransaction is a row with block data, as tracked for finality and reorgs
+type MinedTransaction struct {
+	ID          string
+	Hash        string
+	ChainID     int
+	Status      models.Status
+	BlockNumber int64
+	BlockHash   string
+}
+
+// ChainData is the normalized on-chain view of a transaction
+type ChainData struct {
+	FromAddress string
+	ToAddress   string
+	Value       string
+	BlockHash   string
+	// BlockNumber, GasUsed and Confirmations are nil until the transaction
+	// is mined and the receipt read
+	BlockNumber   *int64
+	GasUsed       *int64
+	Confirmations *int64
+}
+
+// Transition is a status change and why it happened
+type Transition struct {
+	ID   string
+	From models.Status // the status the caller expects the row to be in
+	To   models.Status
+	// Reason is the event reason; for Transition it is also stored as
+	// error_reason, and an empty reason records a generic one
+	Reason string
+	// Provider is the RPC provider whose data caused the change, if any
+	Provider string
+	// ClaimedBy is the worker whose lease the change is made under; required
+	// when From is an in-flight status
+	ClaimedBy string
+	// ChainData, if set, is stored by Transition together with the status
+	ChainData *ChainData
+}
+
+// Retry describes a failed attempt that will be tried again
+type Retry struct {
+	ID        string
+	From      models.Status
+	ClaimedBy string
+	LastError string
+	Reason    string
+	Delay     time.Duration
+	Provider  string
+	// RefundAttempt gives back the attempt the claim counted, for failures
+	// that say nothing about the transaction itself
+	RefundAttempt bool
+}
@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/backoff"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxResponseBody is how much of a receiver's response we keep per attempt
+const maxResponseBody = 2048
+
+// Dispatcher POSTs pending deliveries to their subscribers, retrying with
+// exponential backoff until MaxAttempts, after which a delivery is FAILED
+type Dispatcher struct {
+	DB         *pgxpool.Pool
+	HTTPClient *http.Client
+
+	PollInterval time.Duration
+	BatchSize    int
+	Concurrency  int
+
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	stopChan chan struct{}
+}
+
+// NewDispatcher creates a dispatcher with default settings
+func NewDispatcher(db *pgxpool.Pool) *Dispatcher {
+	return &Dispatcher{
+		DB:         db,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+
+		PollInterval: 2 * time.Second,
+		BatchSize:    50,
+		Concurrency:  8,
+
+		MaxAttempts: 8,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    1 * time.Hour,
+
+		stopChan: make(chan struct{}),
+	}
+}
+
+// pendingDelivery is a claimed delivery together with where to send it
+type pendingDelivery struct {
+	ID        string
+	EventType string
+	URL       string
+	Secret    string
+	Payload   []byte
+	Attempt   int
+}
+
+// Start runs the delivery loop until the context is cancelled or Stop is called
+func (d *Dispatcher) Start(ctx context.Context) {
+	log.Printf("📬 Webhook dispatcher started (max %d attempts)", d.MaxAttempts)
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("📬 Webhook dispatcher stopped (context cancelled)")
+			return
+		case <-d.stopChan:
+			log.Println("📬 Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			if err := d.dispatchBatch(ctx); err != nil {
+				log.Printf("❌ Error dispatching webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// Stop signals the dispatcher to stop
+func (d *Dispatcher) Stop() {
+	close(d.stopChan)
+}
+
+// dispatchBatch claims due deliveries and sends them concurrently. A claim
+// hides the row for one client timeout plus slack, so a crashed dispatcher's
+// deliveries are picked up again rather than lost.
+func (d *Dispatcher) dispatchBatch(ctx context.Context) error {
+	claimFor := d.HTTPClient.Timeout + 30*time.Second
+
+	query := `
+		UPDATE webhook_deliveries AS wd
+		SET attempt_count = wd.attempt_count + 1,
+			claimed_until = now() + $2 * interval '1 millisecond',
+			updated_at = now()
+		FROM (
+			SELECT d.id, s.url, s.secret
+			FROM webhook_deliveries AS d
+			JOIN webhook_subscriptions AS s ON s.id = d.subscription_id
+			WHERE d.status = 'PENDING'
+			  AND d.next_attempt_at <= now()
+			  AND (d.claimed_until IS NULL OR d.claimed_until < now())
+			  AND s.active
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		) AS due
+		WHERE wd.id = due.id
+		RETURNING wd.id, wd.event_type, due.url, due.secret, wd.payload, wd.attempt_count
+	`
+
+	rows, err := d.DB.Query(ctx, query, d.BatchSize, claimFor.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	var batch []pendingDelivery
+	for rows.Next() {
+		var p pendingDelivery
+		if err := rows.Scan(&p.ID, &p.EventType, &p.URL, &p.Secret, &p.Payload, &p.Attempt); err != nil {
+			log.Printf("❌ Failed to scan delivery: %v", err)
+			continue
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, max(1, d.Concurrency))
+	var wg sync.WaitGroup
+	for _, p := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p pendingDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := d.deliver(ctx, p); err != nil {
+				log.Printf("❌ Failed to record delivery %s: %v", p.ID, err)
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// deliver sends one delivery and records the attempt and its outcome
+func (d *Dispatcher) deliver(ctx context.Context, p pendingDelivery) error {
+	start := time.Now()
+	statusCode, body, sendErr := d.send(ctx, p)
+	duration := time.Since(start)
+
+	var attemptErr string
+	if sendErr != nil {
+		attemptErr = sendErr.Error()
+	} else if statusCode < 200 || statusCode > 299 {
+		attemptErr = fmt.Sprintf("receiver responded with HTTP %d", statusCode)
+	}
+
+	tx, err := d.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_status, response_body, error, duration_ms)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, ''), $6)
+	`, p.ID, p.Attempt, statusCode, body, attemptErr, duration.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	// Outcomes only apply to deliveries still PENDING: one whose subscription
+	// was deleted mid-flight stays FAILED
+	var tag pgconn.CommandTag
+	switch {
+	case attemptErr == "":
+		tag, err = tx.Exec(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'DELIVERED', delivered_at = now(), claimed_until = NULL, last_error = NULL, updated_at = now()
+			WHERE id = $1 AND status = 'PENDING'
+		`, p.ID)
+		log.Printf("📨 Delivered %s %s to %s", p.EventType, p.ID, p.URL)
+
+	case p.Attempt >= d.MaxAttempts:
+		tag, err = tx.Exec(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'FAILED', claimed_until = NULL, last_error = $2, updated_at = now()
+			WHERE id = $1 AND status = 'PENDING'
+		`, p.ID, attemptErr)
+		log.Printf("💀 Delivery %s to %s failed after %d attempts: %s", p.ID, p.URL, p.Attempt, attemptErr)
+
+	default:
+		delay := backoff.Exponential(d.BaseDelay, d.MaxDelay, p.Attempt-1)
+		tag, err = tx.Exec(ctx, `
+			UPDATE webhook_deliveries
+			SET next_attempt_at = now() + $3 * interval '1 millisecond',
+				claimed_until = NULL, last_error = $2, updated_at = now()
+			WHERE id = $1 AND status = 'PENDING'
+		`, p.ID, attemptErr, delay.Milliseconds())
+		log.Printf("🔁 Delivery %s to %s attempt %d failed: %s; retrying in %s",
+			p.ID, p.URL, p.Attempt, attemptErr, delay)
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Printf("⚠️  Delivery %s was cancelled while in flight; kept its attempt only", p.ID)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// send POSTs the signed payload and returns the receiver's status and a
+// truncated copy of its response body
+func (d *Dispatcher) send(ctx context.Context, p pendingDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TxnFlow-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, p.EventType)
+	req.Header.Set(HeaderDelivery, p.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(p.Secret, timestamp, p.Payload))
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// Postgres TEXT rejects NUL bytes and invalid UTF-8
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	text := strings.ToValidUTF8(strings.ReplaceAll(string(body), "\x00", ""), "")
+	return resp.StatusCode, text, nil
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// EventStatusChanged is the event type sent for every transaction status change
+const EventStatusChanged = "transaction.status_changed"
+
+// Enqueue creates a delivery for every active subscription matching the
+// transaction's new state. It runs inside the caller's transaction, so a
+// delivery exists exactly when the status change commits.
+func Enqueue(ctx context.Context, tx pgx.Tx, txID, previousStatus, newStatus, reason string) (int64, error) {
+	if previousStatus == newStatus {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, transaction_id, event_type, payload)
+		SELECT s.id, t.id, $2, jsonb_build_object(
+			'event', $2::text,
+			'previous_status', $3::text,
+			'status', $4::text,
+			'reason', NULLIF($5, ''),
+			'occurred_at', now(),
+			'transaction', jsonb_build_object(
+				'id', t.id,
+				'transaction_hash', t.transaction_hash,
+				'chain_id', t.chain_id,
+				'status', $4::text,
+				'from_address', t.from_address,
+				'to_address', t.to_address,
+				'value', t.value::text,
+				'block_number', t.block_number,
+				'block_hash', t.block_hash,
+				'gas_used', t.gas_used,
+				'confirmations', t.confirmations,
+				'error_reason', t.error_reason,
+				'source_service', t.source_service,
+				'external_reference', t.external_reference,
+				'metadata', t.metadata
+			)
+		)
+		FROM webhook_subscriptions AS s
+		JOIN transactions AS t ON t.id = $1
+		WHERE s.active
+		  AND (cardinality(s.chain_ids) = 0 OR t.chain_id = ANY(s.chain_ids))
+		  AND (cardinality(s.statuses) = 0 OR $4 = ANY(s.statuses))
+		  AND (s.address IS NULL OR lower(t.from_address) = s.address OR lower(t.to_address) = s.address)
+		  AND (s.source_service IS NULL OR t.source_service = s.source_service)
+	`
+
+	tag, err := tx.Exec(ctx, query, txID, EventStatusChanged, previousStatus, newStatus, reason)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// EnqueueEvent adapts Enqueue to the store's transition hook
+func EnqueueEvent(ctx context.Context, tx pgx.Tx, event models.Event) error {
+	var previousStatus, reason string
+	if event.PreviousStatus != nil {
+		previousStatus = string(*event.PreviousStatus)
+	}
+	if event.Reason != nil {
+		reason = *event.Reason
+	}
+
+	_, err := Enqueue(ctx, tx, event.TransactionID, previousStatus, string(event.NewStatus), reason)
+	return err
+}
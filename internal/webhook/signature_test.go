@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testSecret    = "whsec_test"
+	testTimestamp = 1700000000
+	testBody      = `{"event":"transaction.status_changed"}`
+
+	// HMAC-SHA256 of "1700000000.<testBody>" keyed with testSecret, computed
+	// independently of this package
+	testSignature = "sha256=fbc873f2e5bf71fdf7297a4652360882df728aac3643d2c1ef402920882ab6cb"
+)
+
+func TestSignKnownAnswer(t *testing.T) {
+	if got := Sign(testSecret, testTimestamp, []byte(testBody)); got != testSignature {
+		t.Errorf("Sign = %s, want %s", got, testSignature)
+	}
+	if !Verify(testSecret, testTimestamp, []byte(testBody), testSignature) {
+		t.Error("Verify rejected a valid signature")
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		signature string
+	}{
+		{"tampered body", testSecret, testTimestamp, `{"event":"transaction.status_changed","x":1}`, testSignature},
+		{"tampered timestamp", testSecret, testTimestamp + 1, testBody, testSignature},
+		{"wrong secret", "whsec_other", testTimestamp, testBody, testSignature},
+		{"tampered signature", testSecret, testTimestamp, testBody, testSignature[:len(testSignature)-1] + "0"},
+		{"missing scheme", testSecret, testTimestamp, testBody, strings.TrimPrefix(testSignature, "sha256=")},
+		{"empty signature", testSecret, testTimestamp, testBody, ""},
+	}
+
+	for _, tt := range tests {
+		if Verify(tt.secret, tt.timestamp, []byte(tt.body), tt.signature) {
+			t.Errorf("%s: Verify accepted the signature", tt.name)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret: %v", err)
+	}
+	b, _ := NewSecret()
+
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+64 {
+		t.Errorf("NewSecret = %q, want whsec_ and 32 hex-encoded bytes", a)
+	}
+	if a == b {
+		t.Error("NewSecret returned the same secret twice")
+	}
+}
@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// claimBatch atomically leases up to BatchSize RECEIVED rows that are due (not
+// waiting out a retry backoff) and moves them to FETCHING
+func (w *Worker) claimBatch(ctx context.Context) ([]store.Claim, error) {
+	claimed, err := w.Store.ClaimReceived(ctx, w.WorkerID, w.BatchSize, w.LeaseDuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim RECEIVED transactions: %w", err)
+	}
+	return claimed, nil
+}
+
+// defaultWorkerID combines hostname and PID, which is unique per pod
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+// chainHead is a snapshot of how far a chain has progressed
+type chainHead struct {
+	Head      int64 // Latest block number
+	FinalUpTo int64 // Blocks at or below this number are final
+}
+
+// confirmations returns head minus the transaction's block number
+func (h *chainHead) confirmations(blockNumber int64) int64 {
+	if blockNumber > h.Head {
+		return 0
+	}
+	return h.Head - blockNumber
+}
+
+// isFinal reports whether a block satisfies both the depth and tag rules
+func (h *chainHead) isFinal(blockNumber int64) bool {
+	return blockNumber <= h.FinalUpTo
+}
+
+// fetchChainHead reads the head and, if the chain uses one, the safe/finalized block
+func (w *Worker) fetchChainHead(ctx context.Context, chainID int) (*chainHead, error) {
+	chainConfig, err := w.ChainRegistry.GetChain(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported chain: %w", err)
+	}
+
+	rpcClient, err := w.ChainRegistry.Client(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := rpcClient.GetBlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch block number: %w", err)
+	}
+
+	h := &chainHead{
+		Head:      head,
+		FinalUpTo: head - chainConfig.ConfirmationDepth,
+	}
+
+	if chainConfig.FinalityTag != "" && chainConfig.FinalityTag != blockchain.BlockTagLatest {
+		block, err := rpcClient.GetBlockByNumber(ctx, string(chainConfig.FinalityTag))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s block: %w", chainConfig.FinalityTag, err)
+		}
+
+		tagged, err := blockchain.HexToInt64(block.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s block number: %w", chainConfig.FinalityTag, err)
+		}
+
+		if tagged < h.FinalUpTo {
+			h.FinalUpTo = tagged
+		}
+	}
+
+	return h, nil
+}
+
+// finalizeOutcome resolves the receipt outcome and promotes INCLUDED
+// transactions straight to CONFIRMED when they are already final. head is the
+// chain head fetched once for the whole batch, nil if it couldn't be read.
+func finalizeOutcome(data *BlockchainTransaction, head *chainHead) (models.Status, string) {
+	status, reason := resolveOutcome(data)
+	if status != models.StatusIncluded || head == nil {
+		// Without a head the INCLUDED sweep will pick it up later
+		return status, reason
+	}
+
+	confirmations := head.confirmations(data.BlockNumber)
+	data.Confirmations = &confirmations
+
+	if head.isFinal(data.BlockNumber) {
+		return models.StatusConfirmed, ""
+	}
+	return status, reason
+}
+
+// processIncluded refreshes confirmations for INCLUDED transactions and
+// promotes the ones that have become final to CONFIRMED
+func (w *Worker) processIncluded(ctx context.Context) error {
+	included, err := w.Store.ListIncluded(ctx, w.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to query INCLUDED transactions: %w", err)
+	}
+
+	// One head lookup per chain per sweep
+	heads := make(map[int]*chainHead)
+
+	for _, t := range included {
+		head, ok := heads[t.ChainID]
+		if !ok {
+			head, err = w.fetchChainHead(ctx, t.ChainID)
+			if err != nil {
+				log.Printf("❌ Failed to fetch chain head for chain %d: %v", t.ChainID, err)
+			}
+			heads[t.ChainID] = head
+		}
+		if head == nil {
+			continue
+		}
+
+		confirmations := head.confirmations(t.BlockNumber)
+		if err := w.Store.SetConfirmations(ctx, t.ID, t.BlockNumber, confirmations); err != nil {
+			log.Printf("❌ Failed to update confirmations for %s: %v", t.Hash, err)
+			continue
+		}
+
+		if !head.isFinal(t.BlockNumber) {
+			continue
+		}
+
+		if err := w.updateStatus(ctx, t.ID, models.StatusIncluded, models.StatusConfirmed, ""); err != nil {
+			log.Printf("❌ Failed to confirm transaction %s: %v", t.Hash, err)
+			continue
+		}
+
+		log.Printf("✅ Transaction %s final after %d confirmations", t.Hash, confirmations)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+func TestFinalizeOutcome(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              BlockchainTransaction
+		head              *chainHead
+		wantStatus        models.Status
+		wantReason        string
+		wantConfirmations int64 // -1 when confirmations stay unknown
+	}{
+		{
+			name:              "no receipt",
+			data:              BlockchainTransaction{},
+			head:              &chainHead{Head: 100, FinalUpTo: 97},
+			wantStatus:        models.StatusPending,
+			wantConfirmations: -1,
+		},
+		{
+			name:              "reverted",
+			data:              BlockchainTransaction{Status: "failed", BlockNumber: 90, RevertReason: "not owner"},
+			head:              &chainHead{Head: 100, FinalUpTo: 97},
+			wantStatus:        models.StatusFailed,
+			wantReason:        "execution reverted: not owner",
+			wantConfirmations: -1,
+		},
+		{
+			name:              "no head",
+			data:              BlockchainTransaction{Status: "success", BlockNumber: 90},
+			wantStatus:        models.StatusIncluded,
+			wantConfirmations: -1,
+		},
+		{
+			name:              "too shallow",
+			data:              BlockchainTransaction{Status: "success", BlockNumber: 98},
+			head:              &chainHead{Head: 100, FinalUpTo: 97},
+			wantStatus:        models.StatusIncluded,
+			wantConfirmations: 2,
+		},
+		{
+			name:              "deep enough",
+			data:              BlockchainTransaction{Status: "success", BlockNumber: 97},
+			head:              &chainHead{Head: 100, FinalUpTo: 97},
+			wantStatus:        models.StatusConfirmed,
+			wantConfirmations: 3,
+		},
+		{
+			name:              "deep enough but not yet finalized",
+			data:              BlockchainTransaction{Status: "success", BlockNumber: 95},
+			head:              &chainHead{Head: 100, FinalUpTo: 90},
+			wantStatus:        models.StatusIncluded,
+			wantConfirmations: 5,
+		},
+		{
+			name:              "ahead of a lagging head",
+			data:              BlockchainTransaction{Status: "success", BlockNumber: 101},
+			head:              &chainHead{Head: 100, FinalUpTo: 97},
+			wantStatus:        models.StatusIncluded,
+			wantConfirmations: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			status, reason := finalizeOutcome(&data, tt.head)
+			if status != tt.wantStatus || reason != tt.wantReason {
+				t.Errorf("finalizeOutcome = %s %q, want %s %q", status, reason, tt.wantStatus, tt.wantReason)
+			}
+
+			switch {
+			case tt.wantConfirmations < 0 && data.Confirmations != nil:
+				t.Errorf("confirmations = %d, want unknown", *data.Confirmations)
+			case tt.wantConfirmations >= 0 && (data.Confirmations == nil || *data.Confirmations != tt.wantConfirmations):
+				t.Errorf("confirmations = %v, want %d", data.Confirmations, tt.wantConfirmations)
+			}
+		})
+	}
+}
+
+func TestFetchChainHead(t *testing.T) {
+	node, server := newFakeNode(t)
+	node.setHead(100, 90)
+
+	tests := []struct {
+		tag  blockchain.BlockTag
+		want int64
+	}{
+		{tag: "", want: 97},                           // depth alone
+		{tag: blockchain.BlockTagLatest, want: 97},    // latest adds nothing
+		{tag: blockchain.BlockTagFinalized, want: 90}, // the finalized block is further back
+	}
+
+	for _, tt := range tests {
+		w, _ := newTestWorker(t, server.URL, func(c *blockchain.ChainConfig) {
+			c.FinalityTag = tt.tag
+		})
+
+		head, err := w.fetchChainHead(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("fetchChainHead(%q): %v", tt.tag, err)
+		}
+		if head.Head != 100 || head.FinalUpTo != tt.want {
+			t.Errorf("fetchChainHead(%q) = %+v, want final up to %d", tt.tag, head, tt.want)
+		}
+		if !head.isFinal(tt.want) || head.isFinal(tt.want+1) {
+			t.Errorf("isFinal around %d is wrong for %q", tt.want, tt.tag)
+		}
+	}
+}
+
+func TestProcessIncludedConfirmsOnceFinal(t *testing.T) {
+	ctx := context.Background()
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+
+	node.mine(txHash(1), 10, "0x1")
+	node.setHead(11, 0)
+	txn := submit(t, s, txHash(1))
+	if err := w.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusIncluded {
+		t.Fatalf("status %s, want INCLUDED one block deep", got.Status)
+	}
+
+	// Still short of the three-block depth
+	node.setHead(12, 0)
+	if err := w.processIncluded(ctx); err != nil {
+		t.Fatalf("processIncluded: %v", err)
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusIncluded || got.Confirmations == nil || *got.Confirmations != 2 {
+		t.Fatalf("at depth 2: status %s, confirmations %v", got.Status, got.Confirmations)
+	}
+
+	node.setHead(13, 0)
+	if err := w.processIncluded(ctx); err != nil {
+		t.Fatalf("processIncluded: %v", err)
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusConfirmed || got.Confirmations == nil || *got.Confirmations != 3 {
+		t.Errorf("at depth 3: status %s, confirmations %v; want CONFIRMED", got.Status, got.Confirmations)
+	}
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// probeProviders refreshes head-lag data for every chain's providers and
+// logs the ones requests are currently failing over away from
+func (w *Worker) probeProviders(ctx context.Context) {
+	for _, chainID := range w.ChainRegistry.GetSupportedChains() {
+		client, err := w.ChainRegistry.Client(chainID)
+		if err != nil {
+			continue
+		}
+
+		client.ProbeHeads(ctx)
+
+		for _, h := range client.Health() {
+			if !h.Healthy {
+				log.Printf("⚠️  Provider %s on chain %d unhealthy: head lag %d, error rate %.2f, latency %v, budget remaining %d",
+					h.Name, chainID, h.HeadLag, h.ErrorRate, h.Latency, h.Limiter.BudgetRemaining)
+			}
+		}
+	}
+}
+
+// ProviderUsage is one provider's health and rate limiter counters
+type ProviderUsage struct {
+	ChainID  int    `json:"chain_id"`
+	Provider string `json:"provider"`
+	Healthy  bool   `json:"healthy"`
+	Requests int64  `json:"requests"`
+	Failures int64  `json:"failures"`
+	HeadLag  int64  `json:"head_lag"`
+
+	// LimiterWaits counts requests the rate limiter held back, for
+	// LimiterWaitMs in total
+	LimiterWaits  int64 `json:"limiter_waits"`
+	LimiterWaitMs int64 `json:"limiter_wait_ms"`
+	// BudgetRemaining is today's unspent daily credits, -1 when unlimited
+	BudgetRemaining int64 `json:"budget_remaining"`
+}
+
+// ProviderUsage returns usage for every provider of every supported chain
+func (w *Worker) ProviderUsage() []ProviderUsage {
+	var usage []ProviderUsage
+	for _, chainID := range w.ChainRegistry.GetSupportedChains() {
+		client, err := w.ChainRegistry.Client(chainID)
+		if err != nil {
+			continue
+		}
+
+		for _, h := range client.Health() {
+			usage = append(usage, ProviderUsage{
+				ChainID:         chainID,
+				Provider:        h.Name,
+				Healthy:         h.Healthy,
+				Requests:        h.Requests,
+				Failures:        h.Failures,
+				HeadLag:         h.HeadLag,
+				LimiterWaits:    h.Limiter.Waits,
+				LimiterWaitMs:   h.Limiter.WaitTotal.Milliseconds(),
+				BudgetRemaining: h.Limiter.BudgetRemaining,
+			})
+		}
+	}
+	return usage
+}
+
+// LogProviderUsage logs how often each provider's rate limiter made us wait
+// and how much of its daily credit budget is left (-1 = unlimited)
+func (w *Worker) LogProviderUsage() {
+	for _, u := range w.ProviderUsage() {
+		log.Printf("   - chain %d %s: %d requests, %d limiter waits (%dms), budget remaining %d",
+			u.ChainID, u.Provider, u.Requests, u.LimiterWaits, u.LimiterWaitMs, u.BudgetRemaining)
+	}
+}
+
+// StatusHandler serves the worker's live provider usage as JSON on
+// GET /providers, for operators and metrics scrapers
+func (w *Worker) StatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /providers", func(rw http.ResponseWriter, r *http.Request) {
+		usage := w.ProviderUsage()
+		if usage == nil {
+			usage = []ProviderUsage{}
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"worker_id": w.WorkerID,
+			"providers": usage,
+			"timestamp": time.Now(),
+		})
+	})
+	return mux
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+)
+
+func TestStatusHandlerServesProviderUsage(t *testing.T) {
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, func(c *blockchain.ChainConfig) {
+		c.RateLimit = blockchain.RateLimitConfig{RequestsPerSecond: 1000, Burst: 100, DailyCreditBudget: 1000}
+	})
+
+	node.pend(txHash(1))
+	submit(t, s, txHash(1))
+	if err := w.processBatch(context.Background()); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	w.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/providers", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /providers = %d: %s", rec.Code, rec.Body)
+	}
+
+	var body struct {
+		WorkerID  string          `json:"worker_id"`
+		Providers []ProviderUsage `json:"providers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.WorkerID != "test-worker" || len(body.Providers) != 1 {
+		t.Fatalf("body = %+v", body)
+	}
+
+	// One batch: a transaction and a receipt lookup
+	want := 1000 - blockchain.MethodCost("eth_getTransactionByHash") - blockchain.MethodCost("eth_getTransactionReceipt")
+	if u := body.Providers[0]; u.ChainID != 1 || u.Provider != "stub" || u.Requests != 1 || u.BudgetRemaining != want {
+		t.Errorf("usage = %+v, want 1 request and %d credits left", u, want)
+	}
+}
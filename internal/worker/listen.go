@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/backoff"
+	"github.com/Wuzu11517/TxnFlow/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// listen holds a dedicated connection LISTENing for new transactions and
+// wakes the worker loop on every notification. It reconnects with backoff
+// until ctx is cancelled; while it is down the poll ticker still sweeps.
+func (w *Worker) listen(ctx context.Context) {
+	attempt := 0
+	for {
+		listened, err := w.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Only back off further across failed attempts; a session that got
+		// as far as LISTEN starts the schedule over
+		if listened {
+			attempt = 0
+		}
+
+		delay := backoff.Exponential(time.Second, time.Minute, attempt)
+		log.Printf("⚠️  LISTEN connection lost: %v (reconnecting in %v)", err, delay)
+		attempt++
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// listenOnce runs a single LISTEN session until its connection fails.
+// listened reports whether the session got as far as LISTENing.
+func (w *Worker) listenOnce(ctx context.Context) (listened bool, err error) {
+	// LISTEN is session state, so it can't share pooled connections
+	conn, err := pgx.ConnectConfig(ctx, w.DB.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{db.TransactionsChannel}.Sanitize()); err != nil {
+		return false, err
+	}
+
+	log.Printf("👂 Listening for new transactions on %q", db.TransactionsChannel)
+
+	// Catch anything that arrived while we weren't listening
+	w.wake()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return true, err
+		}
+		w.wake()
+	}
+}
+
+// wake asks the worker loop to claim a batch now. Signals coalesce, so a
+// burst of notifications results in a single extra pass.
+func (w *Worker) wake() {
+	select {
+	case w.wakeChan <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/backoff"
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// processPending re-checks PENDING transactions whose next check is due.
+// Due rows are claimed by pushing next_check_at out by one lease, so other
+// replicas skip them while this worker polls the node.
+func (w *Worker) processPending(ctx context.Context) error {
+	due, err := w.Store.ClaimDuePending(ctx, w.BatchSize, w.LeaseDuration)
+	if err != nil {
+		return fmt.Errorf("failed to query PENDING transactions: %w", err)
+	}
+
+	// One batched round trip per chain for the whole sweep
+	keys := make([]chainHash, len(due))
+	for i, p := range due {
+		keys[i] = chainHash{ChainID: p.ChainID, Hash: p.Hash}
+	}
+	results := w.fetchMany(ctx, keys)
+
+	for i, p := range due {
+		if err := w.recheckPending(ctx, p, results[i]); err != nil {
+			log.Printf("❌ Failed to re-check transaction %s: %v", p.Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// recheckPending applies the node's current view of a PENDING transaction and
+// either promotes it, drops it, or schedules the next check
+func (w *Worker) recheckPending(ctx context.Context, p store.PendingCheck, res fetchResult) error {
+	ctx, trace := blockchain.WithProviderTrace(ctx)
+	trace.Set(res.Provider)
+
+	txData, err := res.Data, res.Err
+	if err != nil {
+		if !errors.Is(err, blockchain.ErrTransactionNotFound) {
+			// Node trouble, try again later
+			return errors.Join(err, w.scheduleRecheck(ctx, p))
+		}
+
+		pendingFor := time.Since(p.PendingSince)
+		if pendingFor < w.PendingDropAfter {
+			return w.scheduleRecheck(ctx, p)
+		}
+
+		reason := fmt.Sprintf("transaction not found on chain after %s pending; dropped", pendingFor.Round(time.Second))
+		if err := w.updateStatus(ctx, p.ID, models.StatusPending, models.StatusDropped, reason); err != nil {
+			return fmt.Errorf("failed to update status to DROPPED: %w", err)
+		}
+
+		log.Printf("🗑️  Transaction %s dropped after %s", p.Hash, pendingFor.Round(time.Second))
+		return nil
+	}
+
+	status, reason := finalizeOutcome(txData, res.Head)
+	if status == models.StatusPending {
+		return w.scheduleRecheck(ctx, p)
+	}
+
+	err = w.Store.Transition(ctx, store.Transition{
+		ID:        p.ID,
+		From:      models.StatusPending,
+		To:        status,
+		Reason:    reason,
+		Provider:  blockchain.ProviderFromContext(ctx),
+		ChainData: chainData(txData),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update status to %s: %w", status, err)
+	}
+
+	log.Printf("✅ Pending transaction %s mined: %s", p.Hash, status)
+	return nil
+}
+
+// scheduleRecheck pushes the next check out using exponential backoff
+func (w *Worker) scheduleRecheck(ctx context.Context, p store.PendingCheck) error {
+	delay := backoff.Exponential(w.PendingRecheckBase, w.PendingRecheckMax, p.CheckCount)
+	return w.Store.ScheduleRecheck(ctx, p.ID, delay)
+}
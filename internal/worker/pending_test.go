@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+func TestPendingDroppedAfterDeadline(t *testing.T) {
+	ctx := context.Background()
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+	w.PendingRecheckBase, w.PendingRecheckMax = time.Millisecond, time.Millisecond
+	w.PendingDropAfter = time.Hour
+
+	node.pend(txHash(1))
+	txn := submit(t, s, txHash(1))
+	if err := w.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+	got := reload(t, s, txn.ID)
+	if got.Status != models.StatusPending || got.BlockNumber != nil || got.GasUsed != nil {
+		t.Fatalf("after first fetch: status %s, block %v, gas %v; want PENDING with no block", got.Status, got.BlockNumber, got.GasUsed)
+	}
+
+	// Gone from the mempool, but still within the deadline
+	node.drop(txHash(1))
+	if err := w.processPending(ctx); err != nil {
+		t.Fatalf("processPending: %v", err)
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusPending {
+		t.Fatalf("status %s before the deadline, want PENDING", got.Status)
+	}
+
+	w.PendingDropAfter = time.Millisecond
+	time.Sleep(5 * time.Millisecond) // let the deadline and the recheck delay pass
+	if err := w.processPending(ctx); err != nil {
+		t.Fatalf("processPending: %v", err)
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusDropped || !strings.Contains(str(got.ErrorReason), "dropped") {
+		t.Errorf("after the deadline: status %s, error_reason %q; want DROPPED", got.Status, str(got.ErrorReason))
+	}
+}
+
+func TestPendingMinedOnRecheck(t *testing.T) {
+	ctx := context.Background()
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+	w.PendingRecheckBase, w.PendingRecheckMax = time.Millisecond, time.Millisecond
+
+	node.pend(txHash(1))
+	txn := submit(t, s, txHash(1))
+	if err := w.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+
+	node.mine(txHash(1), 10, "0x1")
+	node.setHead(11, 0)
+	time.Sleep(5 * time.Millisecond)
+	if err := w.processPending(ctx); err != nil {
+		t.Fatalf("processPending: %v", err)
+	}
+
+	got := reload(t, s, txn.ID)
+	if got.Status != models.StatusIncluded || got.BlockNumber == nil || *got.BlockNumber != 10 || got.Confirmations == nil || *got.Confirmations != 1 {
+		t.Errorf("after mining: %+v, want INCLUDED in block 10 with 1 confirmation", got)
+	}
+}
+
+func str(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
@@ -2,41 +2,115 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Worker polls for RECEIVED transactions and processes them
 type Worker struct {
+	// DB is only used to LISTEN for new transactions; everything else goes through Store
 	DB            *pgxpool.Pool
+	Store         store.TransactionStore
 	ChainRegistry *blockchain.ChainRegistry
 	PollInterval  time.Duration
 	BatchSize     int
-	stopChan      chan struct{}
+
+	// WorkerID identifies this replica on the rows it has claimed
+	WorkerID string
+	// Concurrency bounds how many claimed transactions are processed at once
+	Concurrency int
+	// LeaseDuration is how long a claim stays valid before another worker may take it
+	LeaseDuration time.Duration
+
+	// PENDING re-check schedule: the delay doubles from base up to max
+	// after every check, and rows the node no longer knows about are
+	// marked DROPPED once they've been pending longer than PendingDropAfter.
+	PendingRecheckBase time.Duration
+	PendingRecheckMax  time.Duration
+	PendingDropAfter   time.Duration
+
+	// ReorgCheckInterval controls how often recent block hashes are re-verified
+	ReorgCheckInterval time.Duration
+
+	// ReapInterval controls how often expired leases are returned to RECEIVED
+	ReapInterval time.Duration
+
+	// HealthCheckInterval controls how often provider heads are probed for lag
+	HealthCheckInterval time.Duration
+
+	// Retry policy for failed fetches: retryable errors are re-queued with
+	// jittered exponential backoff, and rows land in ERROR (the dead-letter
+	// state) once MaxAttempts is used up or the error is terminal.
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	stopChan  chan struct{}
+	wakeChan  chan struct{}
+	recovered atomic.Int64
 }
 
 // NewWorker creates a new transaction processing worker
-func NewWorker(db *pgxpool.Pool, chainRegistry *blockchain.ChainRegistry) *Worker {
+func NewWorker(db *pgxpool.Pool, txStore store.TransactionStore, chainRegistry *blockchain.ChainRegistry) *Worker {
 	return &Worker{
 		DB:            db,
+		Store:         txStore,
 		ChainRegistry: chainRegistry,
 		PollInterval:  5 * time.Second, // Poll every 5 seconds
-		BatchSize:     10,               // Process up to 10 transactions per batch
-		stopChan:      make(chan struct{}),
+		BatchSize:     10,              // Process up to 10 transactions per batch
+
+		WorkerID:      defaultWorkerID(),
+		Concurrency:   4,
+		LeaseDuration: 2 * time.Minute,
+
+		PendingRecheckBase: 15 * time.Second,
+		PendingRecheckMax:  5 * time.Minute,
+		PendingDropAfter:   30 * time.Minute,
+		ReorgCheckInterval: 30 * time.Second,
+		ReapInterval:       30 * time.Second,
+
+		HealthCheckInterval: 30 * time.Second,
+
+		MaxAttempts:    5,
+		RetryBaseDelay: 10 * time.Second,
+		RetryMaxDelay:  10 * time.Minute,
+
+		stopChan: make(chan struct{}),
+		wakeChan: make(chan struct{}, 1),
 	}
 }
 
-// Start begins the worker loop
+// Start begins the worker loop. New transactions are picked up as soon as the
+// API notifies us; the poll ticker remains as a fallback sweep.
 func (w *Worker) Start(ctx context.Context) {
-	log.Println("🚀 Worker started - polling for RECEIVED transactions")
+	log.Println("🚀 Worker started - listening and polling for RECEIVED transactions")
+
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	go w.listen(listenCtx)
 
 	ticker := time.NewTicker(w.PollInterval)
 	defer ticker.Stop()
 
+	reorgTicker := time.NewTicker(w.ReorgCheckInterval)
+	defer reorgTicker.Stop()
+
+	reapTicker := time.NewTicker(w.ReapInterval)
+	defer reapTicker.Stop()
+
+	healthTicker := time.NewTicker(w.HealthCheckInterval)
+	defer healthTicker.Stop()
+	w.probeProviders(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -45,11 +119,41 @@ func (w *Worker) Start(ctx context.Context) {
 		case <-w.stopChan:
 			log.Println("⏹️  Worker stopped - stop signal received")
 			return
+		case <-w.wakeChan:
+			// Notified of new transactions, or the last batch was full
+			if err := w.processBatch(ctx); err != nil {
+				log.Printf("❌ Error processing batch: %v", err)
+			}
 		case <-ticker.C:
 			// Process a batch of transactions
 			if err := w.processBatch(ctx); err != nil {
 				log.Printf("❌ Error processing batch: %v", err)
 			}
+
+			// Re-check PENDING transactions that are due
+			if err := w.processPending(ctx); err != nil {
+				log.Printf("❌ Error re-checking pending transactions: %v", err)
+			}
+
+			// Track confirmations until INCLUDED transactions are final
+			if err := w.processIncluded(ctx); err != nil {
+				log.Printf("❌ Error tracking included transactions: %v", err)
+			}
+		case <-reorgTicker.C:
+			// Re-verify recent blocks against the canonical chain
+			if err := w.processReorgs(ctx); err != nil {
+				log.Printf("❌ Error checking for reorgs: %v", err)
+			}
+		case <-reapTicker.C:
+			// Return rows abandoned by crashed workers to the queue
+			if n, err := w.reapExpiredLeases(ctx); err != nil {
+				log.Printf("❌ Error reaping expired leases: %v", err)
+			} else if n > 0 {
+				log.Printf("♻️  Recovered %d stuck transactions", n)
+			}
+		case <-healthTicker.C:
+			// Rank providers down when they fall behind the others
+			w.probeProviders(ctx)
 		}
 	}
 }
@@ -59,106 +163,223 @@ func (w *Worker) Stop() {
 	close(w.stopChan)
 }
 
-// processBatch finds and processes RECEIVED transactions
+// processBatch claims RECEIVED transactions and processes them concurrently
 func (w *Worker) processBatch(ctx context.Context) error {
-	// Find transactions with status RECEIVED
-	query := `
-		SELECT id, transaction_hash, chain_id
-		FROM transactions
-		WHERE status = 'RECEIVED'
-		ORDER BY created_at ASC
-		LIMIT $1
-	`
-
-	rows, err := w.DB.Query(ctx, query, w.BatchSize)
+	claimed, err := w.claimBatch(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to query RECEIVED transactions: %w", err)
+		return err
 	}
-	defer rows.Close()
-
-	count := 0
-	for rows.Next() {
-		var (
-			id    string
-			hash  string
-			chain int
-		)
-
-		if err := rows.Scan(&id, &hash, &chain); err != nil {
-			log.Printf("❌ Failed to scan row: %v", err)
-			continue
-		}
 
-		// Process this transaction
-		if err := w.processTransaction(ctx, id, hash, chain); err != nil {
-			log.Printf("❌ Failed to process transaction %s: %v", hash, err)
-		} else {
-			count++
-		}
+	if len(claimed) == 0 {
+		return nil
 	}
 
-	if count > 0 {
-		log.Printf("✅ Processed %d transactions", count)
+	// A full batch means there is probably more waiting, so go again right away
+	if len(claimed) == w.BatchSize {
+		defer w.wake()
 	}
 
-	return rows.Err()
-}
+	// Keep our leases alive for as long as the batch takes
+	done := make(chan struct{})
+	defer close(done)
+	go w.heartbeat(ctx, done)
+
+	// Fetch every claimed transaction and receipt in one batched call per chain
+	keys := make([]chainHash, len(claimed))
+	for i, c := range claimed {
+		keys[i] = chainHash{ChainID: c.ChainID, Hash: c.Hash}
+	}
+	results := w.fetchMany(ctx, keys)
+
+	// Bounded pool: at most Concurrency transactions in flight at once
+	var (
+		wg    sync.WaitGroup
+		count atomic.Int64
+		sem   = make(chan struct{}, max(w.Concurrency, 1))
+	)
+
+	for i, c := range claimed {
+		sem <- struct{}{}
+		wg.Add(1)
 
-// processTransaction fetches and normalizes a transaction from the blockchain
-func (w *Worker) processTransaction(ctx context.Context, id, hash string, chainID int) error {
-	log.Printf("📥 Processing transaction: %s (chain: %d)", hash, chainID)
+		go func(c store.Claim, res fetchResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-	// Update status to FETCHING
-	if err := w.updateStatus(ctx, id, "FETCHING", ""); err != nil {
-		return fmt.Errorf("failed to update status to FETCHING: %w", err)
+			if err := w.processTransaction(ctx, c, res); err != nil {
+				log.Printf("❌ Failed to process transaction %s: %v", c.Hash, err)
+				return
+			}
+			count.Add(1)
+		}(c, results[i])
 	}
 
-	// Fetch from blockchain
-	txData, err := w.fetchFromBlockchain(ctx, hash, chainID)
-	if err != nil {
-		// Mark as ERROR if fetch failed
-		w.updateStatus(ctx, id, "ERROR", err.Error())
-		return err
+	wg.Wait()
+
+	if n := count.Load(); n > 0 {
+		log.Printf("✅ Processed %d/%d transactions", n, len(claimed))
 	}
 
-	// Normalize and store transaction data
-	if err := w.normalizeAndStore(ctx, id, txData); err != nil {
-		w.updateStatus(ctx, id, "ERROR", err.Error())
-		return fmt.Errorf("failed to normalize transaction: %w", err)
+	return nil
+}
+
+// processTransaction stores the fetched blockchain data for a claimed transaction
+func (w *Worker) processTransaction(ctx context.Context, c store.Claim, res fetchResult) error {
+	id, hash := c.ID, c.Hash
+	log.Printf("📥 Processing transaction: %s (chain: %d, attempt %d)", hash, c.ChainID, c.Attempt)
+
+	// The row was already moved to FETCHING when it was claimed
+
+	// Events written from here on record the provider the data came from
+	ctx, trace := blockchain.WithProviderTrace(ctx)
+	trace.Set(res.Provider)
+
+	// Check the result fetched from the blockchain
+	txData, err := res.Data, res.Err
+	if err != nil {
+		// Retry later, or mark as ERROR if the failure is permanent
+		return errors.Join(err, w.handleFailure(ctx, c, err))
 	}
 
-	// Update status to CONFIRMED
-	if err := w.updateStatus(ctx, id, "CONFIRMED", ""); err != nil {
-		return fmt.Errorf("failed to update status to CONFIRMED: %w", err)
+	// Work out the status implied by the receipt and chain head
+	status, reason := finalizeOutcome(txData, res.Head)
+
+	// Store the normalized data and move to the status implied by the
+	// receipt, unless the lease was reaped and the row has moved on
+	err = w.Store.Transition(ctx, store.Transition{
+		ID:        id,
+		From:      models.StatusFetching,
+		To:        status,
+		Reason:    reason,
+		Provider:  blockchain.ProviderFromContext(ctx),
+		ClaimedBy: w.WorkerID,
+		ChainData: chainData(txData),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update status to %s: %w", status, err)
 	}
 
-	log.Printf("✅ Transaction %s processed successfully", hash)
+	log.Printf("✅ Transaction %s processed: %s", hash, status)
 	return nil
 }
 
-// fetchFromBlockchain fetches real transaction data from blockchain RPC
-func (w *Worker) fetchFromBlockchain(ctx context.Context, hash string, chainID int) (*BlockchainTransaction, error) {
-	// Get chain configuration
-	chainConfig, err := w.ChainRegistry.GetChain(chainID)
-	if err != nil {
-		return nil, fmt.Errorf("unsupported chain: %w", err)
+// resolveOutcome maps the fetched receipt state onto a transaction status.
+// The returned reason is stored as error_reason and is only set for FAILED.
+// Successful receipts are INCLUDED until the chain considers them final.
+func resolveOutcome(data *BlockchainTransaction) (models.Status, string) {
+	switch data.Status {
+	case "success":
+		return models.StatusIncluded, ""
+	case "failed":
+		if data.RevertReason != "" {
+			return models.StatusFailed, "execution reverted: " + data.RevertReason
+		}
+		return models.StatusFailed, "execution reverted"
+	default:
+		// No receipt yet, the transaction has not been mined
+		return models.StatusPending, ""
 	}
+}
 
-	// Create RPC client for this chain
-	rpcClient := blockchain.NewRPCClient(chainConfig.RPCURL)
+// chainHash identifies a transaction to fetch
+type chainHash struct {
+	ChainID int
+	Hash    string
+}
 
-	// Fetch transaction
-	ethTx, err := rpcClient.GetTransactionByHash(ctx, hash)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+// fetchResult is the normalized blockchain view of one hash, or why it couldn't be fetched
+type fetchResult struct {
+	Data *BlockchainTransaction
+	Err  error
+
+	// Provider is the RPC endpoint that served the data
+	Provider string
+
+	// Head is the chain's head, read once per batch when any of its
+	// transactions was mined; nil if it wasn't needed or couldn't be read
+	Head *chainHead
+}
+
+// fetchMany fetches real transaction data from blockchain RPC for many hashes.
+// Hashes are grouped per chain and each group costs a single batched round
+// trip, plus one head lookup if any of them has been mined. Results are
+// returned in the same order as keys.
+func (w *Worker) fetchMany(ctx context.Context, keys []chainHash) []fetchResult {
+	results := make([]fetchResult, len(keys))
+
+	byChain := make(map[int][]int)
+	for i, k := range keys {
+		byChain[k.ChainID] = append(byChain[k.ChainID], i)
 	}
 
-	// Fetch receipt for gas usage and status
-	receipt, err := rpcClient.GetTransactionReceipt(ctx, hash)
-	if err != nil {
-		// Receipt might not exist for pending transactions
-		log.Printf("⚠️  No receipt found for %s (may be pending): %v", hash, err)
+	for chainID, indexes := range byChain {
+		// Get the RPC client for this chain
+		rpcClient, err := w.ChainRegistry.Client(chainID)
+		if err != nil {
+			for _, i := range indexes {
+				results[i].Err = fmt.Errorf("unsupported chain: %w", err)
+			}
+			continue
+		}
+
+		// Remember which provider answered so it ends up on the events
+		fetchCtx, trace := blockchain.WithProviderTrace(ctx)
+
+		hashes := make([]string, len(indexes))
+		for j, i := range indexes {
+			hashes[j] = keys[i].Hash
+		}
+
+		// Fetch transactions and receipts together; per-hash errors are on the lookups
+		lookups, err := rpcClient.GetTransactionsWithReceipts(fetchCtx, hashes)
+		if err != nil {
+			log.Printf("⚠️  Batch fetch failed for chain %d: %v", chainID, err)
+		}
+		provider := trace.Name()
+
+		// Revert replays and the head lookup below go out on ctx, so whichever
+		// endpoint serves them isn't mistaken for the source of the batch
+		mined := false
+		for j, i := range indexes {
+			results[i].Data, results[i].Err = w.normalizeLookup(ctx, rpcClient, chainID, lookups[j])
+			results[i].Provider = provider
+			mined = mined || (results[i].Data != nil && results[i].Data.Status == "success")
+		}
+		if !mined {
+			continue
+		}
+
+		// One head lookup covers every transaction of the chain in this batch
+		head, err := w.fetchChainHead(ctx, chainID)
+		if err != nil {
+			log.Printf("⚠️  Failed to fetch chain head for chain %d: %v", chainID, err)
+			continue
+		}
+		for _, i := range indexes {
+			results[i].Head = head
+		}
+	}
+
+	return results
+}
+
+// normalizeLookup converts a fetched transaction and receipt into BlockchainTransaction
+func (w *Worker) normalizeLookup(ctx context.Context, rpcClient *blockchain.RPCClient, chainID int, lookup blockchain.TransactionLookup) (*BlockchainTransaction, error) {
+	hash := lookup.Hash
+
+	if lookup.TxErr != nil {
+		return nil, fmt.Errorf("failed to fetch transaction: %w", lookup.TxErr)
+	}
+	ethTx := lookup.Tx
+
+	receipt := lookup.Receipt
+	if lookup.ReceiptErr != nil {
+		// Receipt doesn't exist for pending transactions
+		if !errors.Is(lookup.ReceiptErr, blockchain.ErrReceiptNotFound) {
+			log.Printf("⚠️  Failed to fetch receipt for %s: %v", hash, lookup.ReceiptErr)
+		}
 		// Continue without receipt data
+		receipt = nil
 	}
 
 	// Convert to normalized format
@@ -167,8 +388,15 @@ func (w *Worker) fetchFromBlockchain(ctx context.Context, hash string, chainID i
 		ChainID:     chainID,
 		FromAddress: ethTx.From,
 		ToAddress:   ethTx.To,
+		BlockHash:   ethTx.BlockHash,
 	}
 
+	// Prefer the receipt's block, it is the one the execution result belongs to
+	blockNumberHex := ethTx.BlockNumber
+	if receipt != nil && receipt.BlockHash != "" {
+		txData.BlockHash = receipt.BlockHash
+		blockNumberHex = receipt.BlockNumber
+	}
 	// Convert hex value to decimal string
 	if ethTx.Value != "" {
 		valueDecimal, err := blockchain.HexToDecimalString(ethTx.Value)
@@ -180,8 +408,8 @@ func (w *Worker) fetchFromBlockchain(ctx context.Context, hash string, chainID i
 	}
 
 	// Parse block number
-	if ethTx.BlockNumber != "" {
-		blockNum, err := blockchain.HexToInt64(ethTx.BlockNumber)
+	if blockNumberHex != "" {
+		blockNum, err := blockchain.HexToInt64(blockNumberHex)
 		if err != nil {
 			log.Printf("⚠️  Failed to parse block number: %v", err)
 		} else {
@@ -198,126 +426,81 @@ func (w *Worker) fetchFromBlockchain(ctx context.Context, hash string, chainID i
 			txData.GasUsed = gasUsed
 		}
 
-		// Parse status from receipt (0x1 = success, 0x0 = failed)
-		if receipt.Status == "0x1" {
+		// Parse status from receipt (0x1 = success, 0x0 = failed).
+		// Pre-Byzantium receipts carry no status, being mined is all we know.
+		if receipt.Status == "0x1" || receipt.Status == "" {
 			txData.Status = "success"
 		} else if receipt.Status == "0x0" {
 			txData.Status = "failed"
+
+			// Replay the call to recover the revert reason, best effort
+			reason, err := rpcClient.GetRevertReason(ctx, ethTx)
+			if err != nil {
+				log.Printf("⚠️  Failed to fetch revert reason for %s: %v", hash, err)
+			} else {
+				txData.RevertReason = reason
+			}
 		}
 	}
 
 	return txData, nil
 }
 
-// updateStatus updates the transaction status and logs the event
-func (w *Worker) updateStatus(ctx context.Context, txID, newStatus, errorReason string) error {
-	// Begin transaction
-	tx, err := w.DB.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	// Get current status
-	var currentStatus string
-	err = tx.QueryRow(ctx, `SELECT status FROM transactions WHERE id = $1`, txID).Scan(&currentStatus)
-	if err != nil {
-		return err
-	}
-
-	// Update transaction status
-	updateQuery := `
-		UPDATE transactions 
-		SET status = $1, updated_at = now(), error_reason = $2
-		WHERE id = $3
-	`
-	_, err = tx.Exec(ctx, updateQuery, newStatus, errorReason, txID)
-	if err != nil {
-		return err
-	}
-
-	// Log status change in ingestion_events
-	eventQuery := `
-		INSERT INTO ingestion_events (transaction_id, previous_status, new_status, reason)
-		VALUES ($1, $2, $3, $4)
-	`
-	reason := fmt.Sprintf("Status changed by worker: %s → %s", currentStatus, newStatus)
-	if errorReason != "" {
-		reason = errorReason
-	}
-
-	_, err = tx.Exec(ctx, eventQuery, txID, currentStatus, newStatus, reason)
-	if err != nil {
-		return err
-	}
-
-	// Commit transaction
-	return tx.Commit(ctx)
+// updateStatus moves the transaction from the status it was read in to
+// newStatus and logs the event. It fails with store.ErrStatusConflict if
+// something else changed the row first.
+func (w *Worker) updateStatus(ctx context.Context, txID string, from, newStatus models.Status, errorReason string) error {
+	return w.Store.Transition(ctx, store.Transition{
+		ID:       txID,
+		From:     from,
+		To:       newStatus,
+		Reason:   errorReason,
+		Provider: blockchain.ProviderFromContext(ctx),
+	})
 }
 
 // BlockchainTransaction represents normalized blockchain data
 type BlockchainTransaction struct {
-	Hash        string
-	ChainID     int
-	FromAddress string
-	ToAddress   string
-	Value       string
-	BlockNumber int64
-	GasUsed     int64
-	Status      string
+	Hash         string
+	ChainID      int
+	FromAddress  string
+	ToAddress    string
+	Value        string
+	BlockNumber  int64
+	BlockHash    string
+	GasUsed      int64
+	Status       string
+	RevertReason string
+
+	// Confirmations is only known once the chain head has been checked
+	Confirmations *int64
 }
 
-// normalizeAndStore updates the transaction with normalized data
-func (w *Worker) normalizeAndStore(ctx context.Context, txID string, data *BlockchainTransaction) error {
-	query := `
-		UPDATE transactions
-		SET 
-			from_address = $1,
-			to_address = $2,
-			value = $3,
-			block_number = $4,
-			gas_used = $5,
-			updated_at = now()
-		WHERE id = $6
-	`
-
-	_, err := w.DB.Exec(ctx, query,
-		data.FromAddress,
-		data.ToAddress,
-		data.Value,
-		data.BlockNumber,
-		data.GasUsed,
-		txID,
-	)
+// chainData is the normalized data stored along with a status change
+func chainData(data *BlockchainTransaction) *store.ChainData {
+	return &store.ChainData{
+		FromAddress:   data.FromAddress,
+		ToAddress:     data.ToAddress,
+		Value:         data.Value,
+		BlockNumber:   known(data.BlockNumber),
+		BlockHash:     data.BlockHash,
+		GasUsed:       known(data.GasUsed),
+		Confirmations: data.Confirmations,
+	}
+}
 
-	return err
+// known returns nil for a block number or gas figure that was never read.
+// Genesis holds no transactions and a mined one always uses gas, so zero
+// can only mean unknown.
+func known(n int64) *int64 {
+	if n == 0 {
+		return nil
+	}
+	return &n
 }
 
 // GetStats returns worker statistics (for monitoring)
 func (w *Worker) GetStats(ctx context.Context) (map[string]int, error) {
-	stats := make(map[string]int)
-
 	// Count transactions by status
-	query := `
-		SELECT status, COUNT(*) 
-		FROM transactions 
-		GROUP BY status
-	`
-
-	rows, err := w.DB.Query(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var status string
-		var count int
-		if err := rows.Scan(&status, &count); err != nil {
-			return nil, err
-		}
-		stats[status] = count
-	}
-
-	return stats, rows.Err()
+	return w.Store.CountByStatus(ctx)
 }
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+// heartbeat keeps extending the leases this worker holds until done is closed,
+// so slow batches aren't reaped out from under a live worker
+func (w *Worker) heartbeat(ctx context.Context, done <-chan struct{}) {
+	interval := w.LeaseDuration / 3
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := w.renewLeases(ctx); err != nil {
+				log.Printf("⚠️  Failed to renew leases: %v", err)
+			}
+		}
+	}
+}
+
+// renewLeases pushes out lease expiry on every in-flight row claimed by this worker
+func (w *Worker) renewLeases(ctx context.Context) error {
+	return w.Store.RenewLeases(ctx, w.WorkerID, w.LeaseDuration)
+}
+
+// reapExpiredLeases returns rows whose lease has expired to RECEIVED so they
+// are claimed again, logging an ingestion event for each. Rows that have
+// already been claimed MaxAttempts times go to ERROR instead, so one that
+// crashes or hangs every worker it lands on is dead-lettered.
+func (w *Worker) reapExpiredLeases(ctx context.Context) (int, error) {
+	reaped, err := w.Store.ReapExpiredLeases(ctx, w.MaxAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap expired leases: %w", err)
+	}
+
+	for _, r := range reaped {
+		if r.NewStatus == models.StatusError {
+			log.Printf("❌ Transaction %s stuck in %s (worker %s) after %d attempts; dead-lettered", r.Hash, r.PreviousStatus, r.ClaimedBy, r.Attempt)
+			continue
+		}
+		log.Printf("♻️  Recovered transaction %s stuck in %s (worker %s)", r.Hash, r.PreviousStatus, r.ClaimedBy)
+	}
+
+	w.recovered.Add(int64(len(reaped)))
+	return len(reaped), nil
+}
+
+// RecoveredCount returns how many stuck transactions this worker has reaped
+func (w *Worker) RecoveredCount() int64 {
+	return w.recovered.Load()
+}
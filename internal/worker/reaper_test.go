@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+func TestReaperRecoversAbandonedClaims(t *testing.T) {
+	ctx := context.Background()
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+
+	node.mine(txHash(1), 10, "0x1")
+	node.setHead(11, 0)
+	txn := submit(t, s, txHash(1))
+
+	// A worker claims the row and dies before its lease runs out
+	if _, err := s.ClaimReceived(ctx, "dead-worker", 1, time.Millisecond); err != nil {
+		t.Fatalf("ClaimReceived: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	n, err := w.reapExpiredLeases(ctx)
+	if err != nil {
+		t.Fatalf("reapExpiredLeases: %v", err)
+	}
+	if n != 1 || w.RecoveredCount() != 1 {
+		t.Fatalf("reaped %d, recovered count %d; want 1", n, w.RecoveredCount())
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusReceived {
+		t.Fatalf("reaped row: status %s, want RECEIVED", got.Status)
+	}
+
+	// A live worker picks it up from there
+	if err := w.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+	if got := reload(t, s, txn.ID); got.Status != models.StatusIncluded {
+		t.Errorf("after the live worker: status %s, want INCLUDED", got.Status)
+	}
+}
+
+func TestReaperDeadLettersRowsThatKeepStalling(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+	txn := submit(t, s, txHash(1))
+
+	// Every claim is abandoned, up to MaxAttempts of them
+	for range w.MaxAttempts {
+		if _, err := s.ClaimReceived(ctx, "dead-worker", 1, time.Millisecond); err != nil {
+			t.Fatalf("ClaimReceived: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := w.reapExpiredLeases(ctx); err != nil {
+			t.Fatalf("reapExpiredLeases: %v", err)
+		}
+	}
+
+	got := reload(t, s, txn.ID)
+	if got.Status != models.StatusError || got.AttemptCount != w.MaxAttempts {
+		t.Errorf("after %d stalled claims: status %s, attempt_count %d; want ERROR", w.MaxAttempts, got.Status, got.AttemptCount)
+	}
+	if w.RecoveredCount() != int64(w.MaxAttempts) {
+		t.Errorf("recovered count %d, want %d", w.RecoveredCount(), w.MaxAttempts)
+	}
+}
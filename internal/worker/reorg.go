@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// minedBlock groups the transactions we stored against one block
+type minedBlock struct {
+	Number int64
+	Hash   string
+	Txns   []store.MinedTransaction
+}
+
+// processReorgs compares stored block hashes against the canonical chain for
+// the last ReorgWindow blocks of every supported chain
+func (w *Worker) processReorgs(ctx context.Context) error {
+	for _, chainID := range w.ChainRegistry.GetSupportedChains() {
+		if err := w.checkChainForReorgs(ctx, chainID); err != nil {
+			log.Printf("❌ Reorg check failed for chain %d: %v", chainID, err)
+		}
+	}
+	return nil
+}
+
+// checkChainForReorgs re-verifies one chain's recent blocks
+func (w *Worker) checkChainForReorgs(ctx context.Context, chainID int) error {
+	chainConfig, err := w.ChainRegistry.GetChain(chainID)
+	if err != nil {
+		return err
+	}
+	if chainConfig.ReorgWindow <= 0 {
+		return nil
+	}
+
+	rpcClient, err := w.ChainRegistry.Client(chainID)
+	if err != nil {
+		return err
+	}
+
+	// Reorg events record the provider that reported the canonical chain
+	ctx, _ = blockchain.WithProviderTrace(ctx)
+
+	head, err := rpcClient.GetBlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block number: %w", err)
+	}
+
+	mined, err := w.Store.ListMinedSince(ctx, chainID, head-chainConfig.ReorgWindow)
+	if err != nil {
+		return fmt.Errorf("failed to query recent blocks: %w", err)
+	}
+
+	// Several transactions usually share a block, so only ask for each block once
+	var blocks []*minedBlock
+	byKey := make(map[string]*minedBlock)
+	for _, m := range mined {
+		key := fmt.Sprintf("%d/%s", m.BlockNumber, strings.ToLower(m.BlockHash))
+		b, ok := byKey[key]
+		if !ok {
+			b = &minedBlock{Number: m.BlockNumber, Hash: m.BlockHash}
+			byKey[key] = b
+			blocks = append(blocks, b)
+		}
+		b.Txns = append(b.Txns, m)
+	}
+
+	canonical := make(map[int64]string)
+	for _, b := range blocks {
+		canonicalHash, ok := canonical[b.Number]
+		if !ok {
+			block, err := rpcClient.GetBlockByNumber(ctx, blockchain.Int64ToHex(b.Number))
+			if err != nil {
+				log.Printf("⚠️  Failed to fetch block %d on chain %d: %v", b.Number, chainID, err)
+				continue
+			}
+			canonicalHash = block.Hash
+			canonical[b.Number] = canonicalHash
+		}
+
+		if strings.EqualFold(canonicalHash, b.Hash) {
+			continue
+		}
+
+		reason := fmt.Sprintf("chain reorg: block %d hash %s orphaned (canonical %s); re-verifying",
+			b.Number, b.Hash, canonicalHash)
+
+		for _, m := range b.Txns {
+			reset, err := w.resetForReorg(ctx, m, reason)
+			if err != nil {
+				log.Printf("❌ Failed to reset transaction %s after reorg: %v", m.Hash, err)
+				continue
+			}
+			if !reset {
+				continue
+			}
+			log.Printf("🔀 Transaction %s was in orphaned block %d, re-queued", m.Hash, b.Number)
+		}
+	}
+
+	return nil
+}
+
+// resetForReorg sends a transaction back to RECEIVED and clears the block data
+// that came from the orphaned block. It returns false if the row has been
+// re-fetched in the meantime.
+func (w *Worker) resetForReorg(ctx context.Context, m store.MinedTransaction, reason string) (bool, error) {
+	// Subscribers told about the inclusion hear it was undone through the store's hook
+	return w.Store.ResetForReorg(ctx, store.Transition{
+		ID:       m.ID,
+		From:     m.Status,
+		To:       models.StatusReceived,
+		Reason:   reason,
+		Provider: blockchain.ProviderFromContext(ctx),
+	}, m.BlockHash)
+}
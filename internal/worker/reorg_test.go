@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+)
+
+func TestReorgResetsOrphanedRows(t *testing.T) {
+	ctx := context.Background()
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+
+	node.setHead(12, 0)
+	node.mine(txHash(1), 10, "0x1")
+	node.mine(txHash(2), 10, "0x1")
+	node.mine(txHash(3), 12, "0x1")
+	orphaned := []string{submit(t, s, txHash(1)).ID, submit(t, s, txHash(2)).ID}
+	kept := submit(t, s, txHash(3)).ID
+	if err := w.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+
+	// The replacement block 10 re-includes the first transaction only
+	node.reorg(10, "0xnew10")
+	node.mine(txHash(1), 10, "0x1")
+	node.pend(txHash(2))
+	before := node.callCount("eth_getBlockByNumber")
+	if err := w.checkChainForReorgs(ctx, 1); err != nil {
+		t.Fatalf("checkChainForReorgs: %v", err)
+	}
+
+	// Blocks 10 and 12, each asked for once however many rows they hold
+	if n := node.callCount("eth_getBlockByNumber") - before; n != 2 {
+		t.Errorf("%d block lookups, want 2", n)
+	}
+
+	for _, id := range orphaned {
+		got := reload(t, s, id)
+		if got.Status != models.StatusReceived || got.BlockHash != nil || got.BlockNumber != nil {
+			t.Errorf("orphaned row: status %s, block %v %v; want RECEIVED with the block cleared", got.Status, got.BlockNumber, got.BlockHash)
+		}
+
+		events, err := s.Events(ctx, id)
+		if err != nil {
+			t.Fatalf("Events: %v", err)
+		}
+		if last := events[len(events)-1]; last.Reason == nil || !strings.Contains(*last.Reason, "chain reorg: block 10") {
+			t.Errorf("reorg event reason = %v", last.Reason)
+		}
+	}
+	if got := reload(t, s, kept); got.Status != models.StatusIncluded {
+		t.Errorf("row in a canonical block: status %s, want INCLUDED", got.Status)
+	}
+
+	// The re-queued rows are fetched again and land in the new block
+	if err := w.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+	if got := reload(t, s, orphaned[0]); got.Status != models.StatusIncluded || str(got.BlockHash) != "0xnew10" {
+		t.Errorf("after re-fetch: status %s, block hash %q", got.Status, str(got.BlockHash))
+	}
+	if got := reload(t, s, orphaned[1]); got.Status != models.StatusPending {
+		t.Errorf("after re-fetch of a transaction back in the mempool: status %s, want PENDING", got.Status)
+	}
+}
+
+func TestReorgCheckDisabledWithoutWindow(t *testing.T) {
+	node, server := newFakeNode(t)
+	w, _ := newTestWorker(t, server.URL, func(c *blockchain.ChainConfig) {
+		c.ReorgWindow = 0
+	})
+
+	if err := w.checkChainForReorgs(context.Background(), 1); err != nil {
+		t.Fatalf("checkChainForReorgs: %v", err)
+	}
+	if n := node.callCount("eth_blockNumber"); n != 0 {
+		t.Errorf("%d head lookups with reorg checks disabled", n)
+	}
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/backoff"
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// handleFailure decides between another attempt and the ERROR dead-letter state
+func (w *Worker) handleFailure(ctx context.Context, c store.Claim, cause error) error {
+	// A spent budget says nothing about the transaction, so it must not use
+	// up the row's attempts: park it until the budget resets instead
+	if errors.Is(cause, blockchain.ErrBudgetExhausted) {
+		return w.deferUntilBudgetReset(ctx, c, cause)
+	}
+
+	retryable := blockchain.IsRetryable(cause)
+
+	if retryable && c.Attempt < w.MaxAttempts {
+		delay := withJitter(backoff.Exponential(w.RetryBaseDelay, w.RetryMaxDelay, c.Attempt-1))
+		reason := fmt.Sprintf("attempt %d/%d failed: %v; retrying in %s",
+			c.Attempt, w.MaxAttempts, cause, delay.Round(time.Second))
+
+		log.Printf("🔁 Transaction %s: %s", c.Hash, reason)
+		return w.scheduleRetry(ctx, c.ID, cause.Error(), reason, delay)
+	}
+
+	reason := cause.Error()
+	if retryable {
+		reason = fmt.Sprintf("giving up after %d attempts: %v", c.Attempt, cause)
+	}
+
+	return w.deadLetter(ctx, c.ID, cause.Error(), reason)
+}
+
+// deferUntilBudgetReset returns a claimed row to RECEIVED without counting the
+// attempt, hidden from claims until shortly after the next UTC midnight
+func (w *Worker) deferUntilBudgetReset(ctx context.Context, c store.Claim, cause error) error {
+	reset := blockchain.BudgetReset(time.Now())
+	// Spread the backlog over the first minute so replicas don't all wake at once
+	delay := time.Until(reset) + rand.N(time.Minute)
+	reason := fmt.Sprintf("%v; deferred until %s", cause, reset.Format(time.RFC3339))
+
+	log.Printf("⏸️  Transaction %s: %s", c.Hash, reason)
+	return w.Store.ScheduleRetry(ctx, store.Retry{
+		ID:            c.ID,
+		From:          models.StatusFetching,
+		ClaimedBy:     w.WorkerID,
+		LastError:     cause.Error(),
+		Reason:        reason,
+		Delay:         delay,
+		Provider:      blockchain.ProviderFromContext(ctx),
+		RefundAttempt: true,
+	})
+}
+
+// scheduleRetry puts a claimed row back in RECEIVED, hidden from claims until
+// next_attempt_at
+func (w *Worker) scheduleRetry(ctx context.Context, txID, lastError, reason string, delay time.Duration) error {
+	return w.Store.ScheduleRetry(ctx, store.Retry{
+		ID:        txID,
+		From:      models.StatusFetching,
+		ClaimedBy: w.WorkerID,
+		LastError: lastError,
+		Reason:    reason,
+		Delay:     delay,
+		Provider:  blockchain.ProviderFromContext(ctx),
+	})
+}
+
+// deadLetter moves a row to ERROR, where it stays until an operator requeues it
+func (w *Worker) deadLetter(ctx context.Context, txID, lastError, reason string) error {
+	return w.Store.DeadLetter(ctx, store.Transition{
+		ID:        txID,
+		From:      models.StatusFetching,
+		To:        models.StatusError,
+		Reason:    reason,
+		Provider:  blockchain.ProviderFromContext(ctx),
+		ClaimedBy: w.WorkerID,
+	}, lastError)
+}
+
+// withJitter spreads retries over [d/2, d) so failed batches don't retry in lockstep
+func withJitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
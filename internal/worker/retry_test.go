@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+)
+
+func TestBudgetExhaustionKeepsRowsQueued(t *testing.T) {
+	ctx := context.Background()
+	node, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, func(c *blockchain.ChainConfig) {
+		// Too small for a single call: every fetch fails on the budget
+		c.RateLimit.DailyCreditBudget = 1
+	})
+
+	var ids []string
+	for i := range 3 {
+		node.mine(txHash(i), 10, "0x1")
+		ids = append(ids, submit(t, s, txHash(i)).ID)
+	}
+
+	// More rounds than MaxAttempts: a spent budget must not use attempts up
+	for range w.MaxAttempts + 1 {
+		if err := w.processBatch(ctx); err != nil {
+			t.Fatalf("processBatch: %v", err)
+		}
+	}
+
+	reset := blockchain.BudgetReset(time.Now())
+	for _, id := range ids {
+		got := reload(t, s, id)
+		if got.Status != "RECEIVED" || got.AttemptCount != 0 {
+			t.Errorf("row after exhaustion: status %s, attempt_count %d; want RECEIVED with no attempts used", got.Status, got.AttemptCount)
+		}
+		if got.NextAttemptAt == nil || got.NextAttemptAt.Before(reset) {
+			t.Errorf("next_attempt_at = %v, want after the budget reset at %v", got.NextAttemptAt, reset)
+		}
+		if got.LastError == nil || !strings.Contains(*got.LastError, blockchain.ErrBudgetExhausted.Error()) {
+			t.Errorf("last_error = %v", got.LastError)
+		}
+	}
+	if n := node.callCount("eth_getTransactionByHash"); n != 0 {
+		t.Errorf("%d calls reached the node over budget", n)
+	}
+}
+
+func TestRetryableFailuresDeadLetterAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeNode(t)
+	w, s := newTestWorker(t, server.URL, nil)
+
+	// The node doesn't know the hash, which may just be a lagging node
+	txn := submit(t, s, txHash(1))
+
+	for range w.MaxAttempts {
+		if err := w.processBatch(ctx); err != nil {
+			t.Fatalf("processBatch: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond) // let the retry backoff pass
+	}
+
+	got := reload(t, s, txn.ID)
+	if got.Status != "ERROR" || got.AttemptCount != w.MaxAttempts {
+		t.Errorf("after %d attempts: status %s, attempt_count %d", w.MaxAttempts, got.Status, got.AttemptCount)
+	}
+}
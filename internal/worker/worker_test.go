@@ -0,0 +1,217 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wuzu11517/TxnFlow/internal/blockchain"
+	"github.com/Wuzu11517/TxnFlow/internal/models"
+	"github.com/Wuzu11517/TxnFlow/internal/store"
+)
+
+// fakeNode is a JSON-RPC node holding a tiny chain the test edits directly
+type fakeNode struct {
+	mu        sync.Mutex
+	head      int64
+	finalized int64
+	blocks    map[int64]string // block number → canonical hash
+	txs       map[string]*blockchain.EthTransaction
+	receipts  map[string]*blockchain.EthTransactionReceipt
+	calls     map[string]int
+}
+
+func newFakeNode(t *testing.T) (*fakeNode, *httptest.Server) {
+	t.Helper()
+
+	node := &fakeNode{
+		blocks:   make(map[int64]string),
+		txs:      make(map[string]*blockchain.EthTransaction),
+		receipts: make(map[string]*blockchain.EthTransactionReceipt),
+		calls:    make(map[string]int),
+	}
+	server := httptest.NewServer(http.HandlerFunc(node.serve))
+	t.Cleanup(server.Close)
+
+	return node, server
+}
+
+// blockHash returns the canonical hash of block n
+func (n *fakeNode) blockHash(number int64) string {
+	if hash, ok := n.blocks[number]; ok {
+		return hash
+	}
+	return fmt.Sprintf("0xb%d", number)
+}
+
+// pend puts a transaction in the mempool
+func (n *fakeNode) pend(hash string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.txs[hash] = &blockchain.EthTransaction{Hash: hash, From: "0xfrom", To: "0xto", Value: "0x2a"}
+	delete(n.receipts, hash)
+}
+
+// mine includes a transaction in block number with receipt status 0x1 or 0x0
+func (n *fakeNode) mine(hash string, number int64, status string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	blockHash, blockNumber := n.blockHash(number), blockchain.Int64ToHex(number)
+	n.txs[hash] = &blockchain.EthTransaction{Hash: hash, From: "0xfrom", To: "0xto", Value: "0x2a", BlockHash: blockHash, BlockNumber: blockNumber}
+	n.receipts[hash] = &blockchain.EthTransactionReceipt{TransactionHash: hash, BlockHash: blockHash, BlockNumber: blockNumber, GasUsed: "0x5208", Status: status}
+}
+
+// drop forgets a transaction, as a node does once it leaves the mempool
+func (n *fakeNode) drop(hash string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.txs, hash)
+	delete(n.receipts, hash)
+}
+
+// setHead moves the chain head and the finalized block
+func (n *fakeNode) setHead(head, finalized int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.head, n.finalized = head, finalized
+}
+
+// reorg replaces block number with one of a different hash
+func (n *fakeNode) reorg(number int64, hash string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.blocks[number] = hash
+}
+
+// callCount returns how many times method was called
+func (n *fakeNode) callCount(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[method]
+}
+
+func (n *fakeNode) serve(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+		var req blockchain.JSONRPCRequest
+		_ = json.Unmarshal(raw, &req)
+		json.NewEncoder(w).Encode(n.answer(req))
+		return
+	}
+
+	var reqs []blockchain.JSONRPCRequest
+	_ = json.Unmarshal(raw, &reqs)
+	responses := make([]blockchain.JSONRPCResponse, len(reqs))
+	for i, req := range reqs {
+		responses[i] = n.answer(req)
+	}
+	json.NewEncoder(w).Encode(responses)
+}
+
+func (n *fakeNode) answer(req blockchain.JSONRPCRequest) blockchain.JSONRPCResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls[req.Method]++
+
+	var result interface{}
+	switch req.Method {
+	case "eth_chainId":
+		result = "0x1"
+	case "eth_blockNumber":
+		result = blockchain.Int64ToHex(n.head)
+	case "eth_getTransactionByHash":
+		if tx, ok := n.txs[req.Params[0].(string)]; ok {
+			result = tx
+		}
+	case "eth_getTransactionReceipt":
+		if receipt, ok := n.receipts[req.Params[0].(string)]; ok {
+			result = receipt
+		}
+	case "eth_getBlockByNumber":
+		number := n.head
+		switch tag := req.Params[0].(string); tag {
+		case "latest":
+		case "safe", "finalized":
+			number = n.finalized
+		default:
+			number, _ = blockchain.HexToInt64(tag)
+		}
+		result = blockchain.EthBlock{Number: blockchain.Int64ToHex(number), Hash: n.blockHash(number)}
+	default:
+		return blockchain.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: &blockchain.RPCError{Code: -32601, Message: "method not found"}}
+	}
+
+	raw, _ := json.Marshal(result)
+	return blockchain.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: raw}
+}
+
+// newTestWorker returns a worker on a MemoryStore for chain 1, served by url.
+// configure may adjust the chain before it is registered.
+func newTestWorker(t *testing.T, url string, configure func(*blockchain.ChainConfig)) (*Worker, *store.MemoryStore) {
+	t.Helper()
+
+	chain := &blockchain.ChainConfig{
+		ChainID:           1,
+		Name:              "Test Chain",
+		Type:              blockchain.ChainTypeEVM,
+		Endpoints:         []blockchain.Endpoint{{Name: "stub", URL: url}},
+		ConfirmationDepth: 3,
+		ReorgWindow:       16,
+	}
+	if configure != nil {
+		configure(chain)
+	}
+
+	registry := blockchain.NewChainRegistry("")
+	registry.RegisterChain(chain)
+
+	txStore := store.NewMemoryStore()
+	w := NewWorker(nil, txStore, registry)
+	w.WorkerID = "test-worker"
+	w.MaxAttempts = 2
+	w.RetryBaseDelay = time.Millisecond
+	w.RetryMaxDelay = time.Millisecond
+
+	return w, txStore
+}
+
+// submit registers a transaction on chain 1
+func submit(t *testing.T, s store.TransactionStore, hash string) models.Transaction {
+	t.Helper()
+
+	txn, _, err := s.Create(context.Background(), store.NewTransaction{TransactionHash: hash, ChainID: 1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return txn
+}
+
+// reload reads a transaction back from the store
+func reload(t *testing.T, s store.TransactionStore, id string) models.Transaction {
+	t.Helper()
+
+	txn, err := s.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	return txn
+}
+
+// txHash returns a distinct, well-formed transaction hash
+func txHash(n int) string {
+	return fmt.Sprintf("0x%064x", n)
+}
@@ -10,11 +10,14 @@ set -e
 
 API_URL="${API_URL:-http://localhost:8080}"
 COUNT="${1:-20}"
+# Space-separated chain IDs to spread transactions over; must be configured chains
+CHAIN_IDS=(${CHAIN_IDS:-1})
 
 echo "============================================================================"
 echo "TxnFlow Sample Data Loader"
 echo "============================================================================"
 echo "API URL: $API_URL"
+echo "Chains: ${CHAIN_IDS[*]}"
 echo "Creating $COUNT sample transactions..."
 echo ""
 
@@ -23,10 +26,12 @@ random_hex() {
     openssl rand -hex 32 2>/dev/null || head -c 32 /dev/urandom | xxd -p -c 64
 }
 
+RUN_ID="$(date +%s)"
+
 # Create transactions
 for i in $(seq 1 $COUNT); do
     HASH="0x$(random_hex)"
-    CHAIN_ID=$((1 + RANDOM % 3))  # Random chain ID 1-3
+    CHAIN_ID=${CHAIN_IDS[$((RANDOM % ${#CHAIN_IDS[@]}))]}
     
     echo "[$i/$COUNT] Creating transaction: $HASH (chain: $CHAIN_ID)"
     
@@ -35,7 +40,9 @@ for i in $(seq 1 $COUNT); do
         -d "{
             \"transaction_hash\": \"$HASH\",
             \"chain_id\": $CHAIN_ID,
-            \"source_service\": \"sample-data-loader\"
+            \"source_service\": \"sample-data-loader\",
+            \"external_reference\": \"sample-$i\",
+            \"metadata\": {\"loader_run\": \"$RUN_ID\"}
         }" > /dev/null
     
     # Small delay to avoid overwhelming the API
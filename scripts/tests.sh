@@ -10,9 +10,18 @@ echo ""
 echo "2. Creating test transaction..."
 RESPONSE=$(curl -s -X POST http://localhost:8080/transactions \
   -H "Content-Type: application/json" \
-  -d '{"transaction_hash":"0xtest123","chain_id":1}')
+  -d '{"transaction_hash":"0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060","chain_id":1}')
 echo "$RESPONSE" | grep -q "RECEIVED" && echo "✅ Transaction created" || echo "❌ Failed to create"
 
+echo ""
+echo "2b. Rejecting malformed hash and unsupported chain..."
+curl -s -X POST http://localhost:8080/transactions \
+  -H "Content-Type: application/json" \
+  -d '{"transaction_hash":"0xtest123","chain_id":1}' | grep -q "invalid_transaction_hash" && echo "✅ Malformed hash rejected" || echo "❌ Malformed hash accepted"
+curl -s -X POST http://localhost:8080/transactions \
+  -H "Content-Type: application/json" \
+  -d '{"transaction_hash":"0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060","chain_id":2}' | grep -q "unsupported_chain" && echo "✅ Unsupported chain rejected" || echo "❌ Unsupported chain accepted"
+
 echo ""
 echo "3. Waiting 10 seconds for worker to process..."
 sleep 10
@@ -23,7 +32,7 @@ curl -s http://localhost:8080/stats | grep -q "CONFIRMED" && echo "✅ Worker pr
 
 echo ""
 echo "5. Getting transaction details..."
-curl -s http://localhost:8080/transactions/0xtest123 | grep -q "from_address" && echo "✅ Transaction fully populated" || echo "❌ Transaction not populated"
+curl -s http://localhost:8080/transactions/0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060 | grep -q "from_address" && echo "✅ Transaction fully populated" || echo "❌ Transaction not populated"
 
 echo ""
 echo "=== Test Complete ==="
\ No newline at end of file